@@ -1,6 +1,10 @@
 package v1alpha1
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"go.uber.org/zap"
 )
 
@@ -8,4 +12,31 @@ import (
 type Context struct {
 	Config *ConfigSpec
 	Logger *zap.SugaredLogger
+
+	// Cancelled when the process is requested to finish, so pending actions are cancelled
+	Context context.Context
+
+	// Directory where the state is stored to survive restarts. Empty means not stored
+	StateDirectory string
+
+	// Source of time to plan and execute the actions. Replaced in tests to simulate whole days
+	Clock Clock
+
+	// Client used for the requests to the providers and integrations. Default one is used when empty
+	HttpClient *http.Client
+}
+
+// Clock represents the source of time used to plan and execute the actions.
+// It's replaced by a fake one in tests, so whole days are simulated in milliseconds
+type Clock interface {
+	// Now return the current moment
+	Now() time.Time
+
+	// Sleep pause the current goroutine for the given duration, or until the given context is cancelled.
+	// It returns false when the pause was interrupted
+	Sleep(ctx context.Context, duration time.Duration) bool
+
+	// Go run the given function in a new goroutine. Goroutines sleeping on the clock must be started this way,
+	// so fake clocks know when all of them are sleeping before moving the time forward
+	Go(function func())
 }
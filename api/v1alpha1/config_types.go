@@ -23,14 +23,70 @@ type SpecificationSpec struct {
 
 // GlobalSpec TODO
 type GlobalSpec struct {
-	IgnorePassedHours bool `yaml:"ignorePassedHours,omitempty"`
+	IgnorePassedHours bool        `yaml:"ignorePassedHours,omitempty"`
+	Horizon           HorizonSpec `yaml:"horizon,omitempty"`
+}
+
+// HorizonSpec TODO
+type HorizonSpec struct {
+	Enabled bool   `yaml:"enabled"`
+	Start   string `yaml:"start"`
+	End     string `yaml:"end"`
 }
 
 // DeviceSpec TODO
 type DeviceSpec struct {
-	Type         string           `yaml:"type"`
-	ActiveHours  int              `yaml:"activeHours"`
-	Integrations IntegrationsSpec `yaml:"integrations"`
+	Type           string                `yaml:"type"`
+	ActiveHours    int                   `yaml:"activeHours"`
+	ActiveDuration string                `yaml:"activeDuration,omitempty"`
+	Power          float64               `yaml:"power,omitempty"`
+	Baseline       DeviceBaselineSpec    `yaml:"baseline,omitempty"`
+	ShutdownPolicy string                `yaml:"shutdownPolicy,omitempty"`
+	Reconciliation ReconciliationSpec    `yaml:"reconciliation,omitempty"`
+	Cop            DeviceCopSpec         `yaml:"cop,omitempty"`
+	Constraints    DeviceConstraintsSpec `yaml:"constraints,omitempty"`
+	Windows        []DeviceWindowSpec    `yaml:"windows,omitempty"`
+	Integrations   IntegrationsSpec      `yaml:"integrations"`
+}
+
+// DeviceConstraintsSpec TODO
+type DeviceConstraintsSpec struct {
+	MinRunDuration  string `yaml:"minRunDuration,omitempty"`
+	MinOffDuration  string `yaml:"minOffDuration,omitempty"`
+	MaxCyclesPerDay int    `yaml:"maxCyclesPerDay,omitempty"`
+}
+
+// DeviceBaselineSpec TODO
+type DeviceBaselineSpec struct {
+	Type  string `yaml:"type,omitempty"`
+	Start string `yaml:"start,omitempty"`
+}
+
+// ReconciliationSpec TODO
+type ReconciliationSpec struct {
+	Enabled  bool   `yaml:"enabled"`
+	Interval string `yaml:"interval,omitempty"`
+}
+
+// DeviceCopSpec TODO
+type DeviceCopSpec struct {
+	Enabled bool           `yaml:"enabled"`
+	Curve   []CopPointSpec `yaml:"curve"`
+}
+
+// CopPointSpec TODO
+type CopPointSpec struct {
+	Temperature float64 `yaml:"temperature"`
+	Cop         float64 `yaml:"cop"`
+}
+
+// DeviceWindowSpec TODO
+type DeviceWindowSpec struct {
+	Type        string   `yaml:"type"`
+	Days        []string `yaml:"days,omitempty"`
+	Start       string   `yaml:"start"`
+	End         string   `yaml:"end"`
+	MinDuration string   `yaml:"minDuration,omitempty"`
 }
 
 // IntegrationsSpec TODO
@@ -46,8 +102,16 @@ type IntegrationsSpec struct {
 // WeatherSpec TODO
 type WeatherSpec struct {
 	Enabled     bool            `yaml:"enabled"`
+	Provider    string          `yaml:"provider,omitempty"`
 	Coordinates CoordinatesSpec `yaml:"coordinates,omitempty"`
 	Temperature TemperatureSpec `yaml:"temperature,omitempty"`
+	Evaluation  EvaluationSpec  `yaml:"evaluation,omitempty"`
+	Scaling     ScalingSpec     `yaml:"scaling,omitempty"`
+
+	// Configuration for each weather provider
+	OpenMeteo OpenMeteoSpec      `yaml:"openMeteo,omitempty"`
+	Metno     MetnoSpec          `yaml:"metno,omitempty"`
+	Station   WeatherStationSpec `yaml:"station,omitempty"`
 }
 
 // CoordinatesSpec TODO
@@ -58,12 +122,71 @@ type CoordinatesSpec struct {
 
 // TemperatureSpec TODO
 type TemperatureSpec struct {
-	Type      string `yaml:"type"`
-	Unit      string `yaml:"unit"`
-	Threshold int    `yaml:"threshold"`
+	Type      string   `yaml:"type"`
+	Unit      string   `yaml:"unit"`
+	Threshold *float64 `yaml:"threshold,omitempty"`
+
+	// (Optional) hysteresis band around the threshold. Between both, the previous decision is kept
+	LowThreshold  *float64 `yaml:"lowThreshold,omitempty"`
+	HighThreshold *float64 `yaml:"highThreshold,omitempty"`
+}
+
+// EvaluationSpec TODO
+type EvaluationSpec struct {
+	Period      string `yaml:"period,omitempty"`
+	Aggregation string `yaml:"aggregation,omitempty"`
+
+	// Percentile, between 0 and 100, for 'percentile' aggregation
+	Percentile float64 `yaml:"percentile,omitempty"`
+
+	// Range of hours, expressed as HH:MM in local time, for 'nightMean' aggregation
+	NightStart string `yaml:"nightStart,omitempty"`
+	NightEnd   string `yaml:"nightEnd,omitempty"`
+}
+
+// ScalingSpec TODO
+type ScalingSpec struct {
+	Enabled bool   `yaml:"enabled"`
+	Mode    string `yaml:"mode,omitempty"`
+
+	// Limits for the active duration
+	MinDuration string `yaml:"minDuration,omitempty"`
+	MaxDuration string `yaml:"maxDuration,omitempty"`
+
+	// Temperatures for 'linear' mode
+	WarmTemperature float64 `yaml:"warmTemperature,omitempty"`
+	ColdTemperature float64 `yaml:"coldTemperature,omitempty"`
+
+	// Reference temperature and time per degree hour for 'degreeHours' mode
+	BaseTemperature       float64 `yaml:"baseTemperature,omitempty"`
+	DurationPerDegreeHour string  `yaml:"durationPerDegreeHour,omitempty"`
 }
 
 // PriceSpec TODO
 type PriceSpec struct {
-	Zone string `yaml:"zone"`
+	Provider  string         `yaml:"provider,omitempty"`
+	Providers []string       `yaml:"providers,omitempty"`
+	Zone      string         `yaml:"zone"`
+	Cache     PriceCacheSpec `yaml:"cache,omitempty"`
+
+	// Taxes, tolls and surcharges applied on top of the prices returned by the provider
+	Composition PriceCompositionSpec `yaml:"composition,omitempty"`
+
+	// Thresholds to ignore or force slots, no matter the active duration
+	MaxPrice       *float64 `yaml:"maxPrice,omitempty"`
+	AlwaysRunBelow *float64 `yaml:"alwaysRunBelow,omitempty"`
+
+	// Specific configuration for each provider
+	ApagaLuz ApagaLuzSpec `yaml:"apagaluz,omitempty"`
+	Esios    EsiosSpec    `yaml:"esios,omitempty"`
+	Entsoe   EntsoeSpec   `yaml:"entsoe,omitempty"`
+	NordPool NordPoolSpec `yaml:"nordpool,omitempty"`
+	Tibber   TibberSpec   `yaml:"tibber,omitempty"`
+	Awattar  AwattarSpec  `yaml:"awattar,omitempty"`
+	Octopus  OctopusSpec  `yaml:"octopus,omitempty"`
+	Tou      TouPriceSpec `yaml:"tou,omitempty"`
+
+	// Configuration for generic providers
+	File        FilePriceSpec        `yaml:"file,omitempty"`
+	GenericHttp GenericHttpPriceSpec `yaml:"genericHttp,omitempty"`
 }
@@ -17,4 +17,7 @@ type WebhookSpec struct {
 		Username string `yaml:"username"`
 		Password string `yaml:"password"`
 	} `yaml:"auth,omitempty"`
+
+	// (Optional) send an event with the schedules and their estimated cost each time a plan is crafted
+	SendPlanEvents bool `yaml:"sendPlanEvents,omitempty"`
 }
@@ -0,0 +1,164 @@
+package v1alpha1
+
+// PriceCacheSpec TODO
+type PriceCacheSpec struct {
+	Directory string `yaml:"directory"`
+}
+
+// ApagaLuzSpec TODO
+type ApagaLuzSpec struct {
+	// (Optional) URL of the document with the prices. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// EsiosSpec TODO
+type EsiosSpec struct {
+	Token string `yaml:"token"`
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// EntsoeSpec TODO
+type EntsoeSpec struct {
+	Token string `yaml:"token"`
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// NordPoolSpec TODO
+type NordPoolSpec struct {
+	Currency string `yaml:"currency,omitempty"`
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// TibberSpec TODO
+type TibberSpec struct {
+	Token    string `yaml:"token"`
+	HomeId   string `yaml:"homeId,omitempty"`
+	Timezone string `yaml:"timezone,omitempty"`
+
+	// (Optional) time covered by each price requested. Possible values: hourly (default), quarterHourly
+	Resolution string `yaml:"resolution,omitempty"`
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+}
+
+// AwattarSpec TODO
+type AwattarSpec struct {
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// OctopusSpec TODO
+type OctopusSpec struct {
+	ProductCode string `yaml:"productCode,omitempty"`
+	TariffCode  string `yaml:"tariffCode,omitempty"`
+	Vat         string `yaml:"vat,omitempty"`
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// FilePriceSpec TODO
+type FilePriceSpec struct {
+	Path      string           `yaml:"path"`
+	Format    string           `yaml:"format,omitempty"`
+	Delimiter string           `yaml:"delimiter,omitempty"`
+	Mapping   PriceMappingSpec `yaml:"mapping"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// GenericHttpPriceSpec TODO
+type GenericHttpPriceSpec struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Mapping PriceMappingSpec  `yaml:"mapping"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// PriceMappingSpec represents where the prices are inside a document, and which fields hold their data
+type PriceMappingSpec struct {
+	Items           string                 `yaml:"items,omitempty"`
+	Fields          PriceMappingFieldsSpec `yaml:"fields"`
+	TimestampLayout string                 `yaml:"timestampLayout,omitempty"`
+	Unit            string                 `yaml:"unit,omitempty"`
+	Timezone        string                 `yaml:"timezone,omitempty"`
+}
+
+// PriceMappingFieldsSpec TODO
+type PriceMappingFieldsSpec struct {
+	Timestamp string `yaml:"timestamp"`
+	Price     string `yaml:"price"`
+	Unit      string `yaml:"unit,omitempty"`
+}
+
+// TouPriceSpec TODO
+type TouPriceSpec struct {
+
+	// (Optional) price for every hour, for fixed-price contracts. When defined, periods are ignored
+	Fixed *float64 `yaml:"fixed,omitempty"`
+
+	Periods TouPeriodsSpec `yaml:"periods,omitempty"`
+
+	// (Optional) additional holidays with the format YYYY-MM-DD. National fixed holidays are always included
+	Holidays []string `yaml:"holidays,omitempty"`
+
+	// (Optional) zone used by this provider. 'price.zone' is used when empty
+	Zone string `yaml:"zone,omitempty"`
+}
+
+// TouPeriodsSpec TODO
+type TouPeriodsSpec struct {
+	Punta float64 `yaml:"punta"`
+	Llano float64 `yaml:"llano"`
+	Valle float64 `yaml:"valle"`
+}
+
+// PriceCompositionSpec TODO
+type PriceCompositionSpec struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Percentages applied on top of the energy price
+	ElectricityTax float64 `yaml:"electricityTax,omitempty"`
+	Vat            float64 `yaml:"vat,omitempty"`
+
+	// Amounts added to the energy price, expressed in €/kWh
+	Margin float64        `yaml:"margin,omitempty"`
+	Tolls  TouPeriodsSpec `yaml:"tolls,omitempty"`
+
+	// (Optional) zone and additional holidays used to know the 2.0TD period of the tolls. Default: mainland.
+	// Only Spanish zones are supported, and they are ignored when no tolls are defined
+	Zone     string   `yaml:"zone,omitempty"`
+	Holidays []string `yaml:"holidays,omitempty"`
+
+	// (Optional) price used to compare against 'price.maxPrice' and 'price.alwaysRunBelow'. Default: final
+	ThresholdsBasis string `yaml:"thresholdsBasis,omitempty"`
+}
@@ -0,0 +1,45 @@
+package v1alpha1
+
+// OpenMeteoSpec TODO
+type OpenMeteoSpec struct {
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+}
+
+// MetnoSpec TODO
+type MetnoSpec struct {
+
+	// (Optional) identification sent on each request, as required by MET Norway terms of service
+	UserAgent string `yaml:"userAgent,omitempty"`
+
+	// (Optional) base URL of the API. Useful to point to a different server
+	URL string `yaml:"url,omitempty"`
+}
+
+// WeatherStationSpec TODO
+type WeatherStationSpec struct {
+
+	// Location of the export. Path is used by 'file' provider, and URL by 'http' provider
+	Path    string            `yaml:"path,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	Format    string             `yaml:"format,omitempty"`
+	Delimiter string             `yaml:"delimiter,omitempty"`
+	Mapping   WeatherMappingSpec `yaml:"mapping"`
+}
+
+// WeatherMappingSpec represents where the temperatures are inside a document, and which fields hold their data
+type WeatherMappingSpec struct {
+	Items           string                   `yaml:"items,omitempty"`
+	Fields          WeatherMappingFieldsSpec `yaml:"fields"`
+	TimestampLayout string                   `yaml:"timestampLayout,omitempty"`
+	Timezone        string                   `yaml:"timezone,omitempty"`
+}
+
+// WeatherMappingFieldsSpec TODO
+type WeatherMappingFieldsSpec struct {
+	Timestamp   string `yaml:"timestamp"`
+	Temperature string `yaml:"temperature"`
+}
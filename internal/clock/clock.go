@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// SystemClock represents the clock of the system, implementing v1alpha1.Clock
+type SystemClock struct{}
+
+// Now return the current moment according to the system
+func (c SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep pause the current goroutine for the given duration, or until the given context is cancelled.
+// It returns false when the pause was interrupted
+func (c SystemClock) Sleep(ctx context.Context, duration time.Duration) bool {
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Go run the given function in a new goroutine
+func (c SystemClock) Go(function func()) {
+	go function()
+}
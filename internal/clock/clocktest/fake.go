@@ -0,0 +1,189 @@
+// ATTENTION:
+// This package is only intended to be imported by tests. Goroutines using a FakeClock are tracked explicitly,
+// so the time is moved forward once all of them are sleeping on it, no matter how loaded the machine is
+
+package clocktest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeClock represents a clock whose time only moves forward when it's requested.
+// Goroutines sleeping on it are woken up in order by RunUntil, so whole days are simulated deterministically.
+// Every goroutine sleeping on it must be started by Go, so it's known when all of them are sleeping
+type FakeClock struct {
+	mutex    sync.Mutex
+	changed  *sync.Cond
+	now      time.Time
+	sleepers []*fakeSleeper
+
+	// Goroutines started by Go that are not sleeping on the clock
+	running int
+}
+
+// fakeSleeper represents a goroutine sleeping on a FakeClock until the deadline
+type fakeSleeper struct {
+	deadline time.Time
+	wake     chan struct{}
+}
+
+// NewFakeClock return a fake clock stopped at the given moment
+func NewFakeClock(now time.Time) *FakeClock {
+
+	clock := &FakeClock{now: now}
+	clock.changed = sync.NewCond(&clock.mutex)
+
+	return clock
+}
+
+// Now return the current moment of the fake clock
+func (c *FakeClock) Now() time.Time {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// Go run the given function in a new goroutine, counted as running until it sleeps on the clock or finishes
+func (c *FakeClock) Go(function func()) {
+
+	c.mutex.Lock()
+	c.running++
+	c.mutex.Unlock()
+
+	go func() {
+		defer c.setRunning(-1)
+		function()
+	}()
+}
+
+// Sleep pause the current goroutine until the fake clock reaches the given duration from now,
+// or until the given context is cancelled. It returns false when the pause was interrupted
+func (c *FakeClock) Sleep(ctx context.Context, duration time.Duration) bool {
+
+	c.mutex.Lock()
+
+	if duration <= 0 || ctx.Err() != nil {
+		c.mutex.Unlock()
+		return ctx.Err() == nil
+	}
+
+	sleeper := &fakeSleeper{
+		deadline: c.now.Add(duration),
+		wake:     make(chan struct{}),
+	}
+	c.sleepers = append(c.sleepers, sleeper)
+	c.running--
+	c.changed.Broadcast()
+
+	c.mutex.Unlock()
+
+	select {
+	case <-sleeper.wake:
+		return true
+	case <-ctx.Done():
+		c.cancelSleeper(sleeper)
+		return false
+	}
+}
+
+// Sleepers return the number of goroutines sleeping on the fake clock
+func (c *FakeClock) Sleepers() int {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.sleepers)
+}
+
+// BlockUntil wait until the given number of goroutines are sleeping on the fake clock.
+// It's useful to know that the goroutines under test are started before moving the clock
+func (c *FakeClock) BlockUntil(sleepers int) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(c.sleepers) < sleepers {
+		c.changed.Wait()
+	}
+}
+
+// RunUntil move the fake clock forward until the given moment, waking up the sleeping goroutines in order.
+// Before each step, it waits for every goroutine started by Go to sleep again or finish
+func (c *FakeClock) RunUntil(moment time.Time) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for {
+		for c.running > 0 {
+			c.changed.Wait()
+		}
+
+		var next *fakeSleeper
+		for _, sleeper := range c.sleepers {
+			if next == nil || sleeper.deadline.Before(next.deadline) {
+				next = sleeper
+			}
+		}
+
+		if next == nil || next.deadline.After(moment) {
+			if moment.After(c.now) {
+				c.now = moment
+			}
+			return
+		}
+
+		if next.deadline.After(c.now) {
+			c.now = next.deadline
+		}
+
+		// Wake up every goroutine whose deadline is reached. They are running from now on
+		var sleepers []*fakeSleeper
+		for _, sleeper := range c.sleepers {
+			if sleeper.deadline.After(c.now) {
+				sleepers = append(sleepers, sleeper)
+				continue
+			}
+
+			close(sleeper.wake)
+			c.running++
+		}
+		c.sleepers = sleepers
+	}
+}
+
+// Advance move the fake clock forward the given duration, as RunUntil does
+func (c *FakeClock) Advance(duration time.Duration) {
+	c.RunUntil(c.Now().Add(duration))
+}
+
+// setRunning add the given delta to the goroutines running, notifying the ones waiting for them
+func (c *FakeClock) setRunning(delta int) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.running += delta
+	c.changed.Broadcast()
+}
+
+// cancelSleeper remove the given sleeper, interrupted by its context, from the list of sleeping goroutines.
+// The goroutine is running again, unless it was woken up at the same time, as it's already counted then
+func (c *FakeClock) cancelSleeper(sleeper *fakeSleeper) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for index, item := range c.sleepers {
+		if item == sleeper {
+			c.sleepers = append(c.sleepers[:index], c.sleepers[index+1:]...)
+			c.running++
+			c.changed.Broadcast()
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package clocktest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFakeClockRunUntil(t *testing.T) {
+
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	var mutex sync.Mutex
+	var wakeUps []time.Time
+
+	// Each goroutine records the moments when it's woken up
+	for _, duration := range []time.Duration{3 * time.Hour, time.Hour} {
+		duration := duration
+		clock.Go(func() {
+			for clock.Sleep(context.Background(), duration) {
+				mutex.Lock()
+				wakeUps = append(wakeUps, clock.Now())
+				mutex.Unlock()
+			}
+		})
+	}
+
+	clock.BlockUntil(2)
+	clock.RunUntil(start.Add(3*time.Hour + 30*time.Minute))
+
+	if !clock.Now().Equal(start.Add(3*time.Hour + 30*time.Minute)) {
+		t.Fatalf("expected the clock at %s, got %s", start.Add(3*time.Hour+30*time.Minute), clock.Now())
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	expected := []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 3 * time.Hour}
+	if len(wakeUps) != len(expected) {
+		t.Fatalf("expected %d wake ups, got %d: %v", len(expected), len(wakeUps), wakeUps)
+	}
+
+	for index, duration := range expected {
+		if !wakeUps[index].Equal(start.Add(duration)) {
+			t.Errorf("expected wake up at %s, got %s", start.Add(duration), wakeUps[index])
+		}
+	}
+}
+
+func TestFakeClockSleepCancelled(t *testing.T) {
+
+	clock := NewFakeClock(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result := make(chan bool)
+	clock.Go(func() {
+		result <- clock.Sleep(ctx, time.Hour)
+	})
+
+	clock.BlockUntil(1)
+	cancel()
+
+	if <-result {
+		t.Fatal("expected the sleep to be interrupted")
+	}
+
+	if clock.Sleepers() != 0 {
+		t.Fatalf("expected no sleepers, got %d", clock.Sleepers())
+	}
+}
+
+func TestFakeClockWaitsForRunningGoroutines(t *testing.T) {
+
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	// The goroutine takes a while to sleep again after being woken up, like one performing a request
+	wakeUp := make(chan time.Time, 1)
+	clock.Go(func() {
+		clock.Sleep(context.Background(), time.Hour)
+		time.Sleep(50 * time.Millisecond)
+
+		clock.Sleep(context.Background(), time.Hour)
+		wakeUp <- clock.Now()
+	})
+
+	clock.RunUntil(start.Add(3 * time.Hour))
+
+	select {
+	case moment := <-wakeUp:
+		if !moment.Equal(start.Add(2 * time.Hour)) {
+			t.Errorf("expected wake up at %s, got %s", start.Add(2*time.Hour), moment)
+		}
+	default:
+		t.Fatal("expected the goroutine to be woken up twice")
+	}
+}
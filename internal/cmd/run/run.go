@@ -1,12 +1,18 @@
 package run
 
 import (
+	"context"
 	"fmt"
 	"log"
 	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock"
 	"github.com/achetronic/autoheater/internal/config"
 	"github.com/achetronic/autoheater/internal/schedules"
 
@@ -24,6 +30,7 @@ const (
 	ConfigFlagErrorMessage       = "impossible to get flag --config: %s"
 	LogLevelFlagErrorMessage     = "impossible to get flag --log-level: %s"
 	DisableTraceFlagErrorMessage = "impossible to get flag --disable-trace: %s"
+	StateDirFlagErrorMessage     = "impossible to get flag --state-dir: %s"
 	ConfigNotParsedErrorMessage  = "impossible to parse config file: %s"
 	EnvNotParsedErrorMessage     = "impossible to parse environment variables: %s"
 )
@@ -41,10 +48,26 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().String("config", "autoheater.yaml", "Path to the YAML config file")
 	cmd.Flags().String("log-level", "info", "Verbosity level for logs")
 	cmd.Flags().Bool("disable-trace", false, "Disable showing traces in logs")
+	cmd.Flags().String("state-dir", getDefaultStateDirectory(), "Path to the directory where the state is stored to survive restarts")
 
 	return cmd
 }
 
+// getDefaultStateDirectory return an absolute directory, writable by the current user, to store the state.
+// It follows XDG base directories, as the working directory can be read-only, like in containers
+func getDefaultStateDirectory() string {
+
+	if stateHome := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(stateHome) {
+		return filepath.Join(stateHome, "autoheater")
+	}
+
+	if homeDirectory, err := os.UserHomeDir(); err == nil && filepath.IsAbs(homeDirectory) {
+		return filepath.Join(homeDirectory, ".local", "state", "autoheater")
+	}
+
+	return filepath.Join(os.TempDir(), "autoheater")
+}
+
 // RunCommand executes the main actions of your application
 // https://open-meteo.com/en/docs#latitude=28.0930127&longitude=-16.6357443&hourly=temperature_2m,relativehumidity_2m,apparent_temperature&timezone=Europe%2FLondon&forecast_days=1
 func RunCommand(cmd *cobra.Command, args []string) {
@@ -67,6 +90,11 @@ func RunCommand(cmd *cobra.Command, args []string) {
 		log.Fatalf(DisableTraceFlagErrorMessage, err)
 	}
 
+	stateDirFlag, err := cmd.Flags().GetString("state-dir")
+	if err != nil {
+		log.Fatalf(StateDirFlagErrorMessage, err)
+	}
+
 	//
 	logLevel, _ := zap.ParseAtomicLevel(logLevelFlag)
 
@@ -90,8 +118,10 @@ func RunCommand(cmd *cobra.Command, args []string) {
 
 	// Configure application's context
 	ctx = v1alpha1.Context{
-		Config: &v1alpha1.ConfigSpec{},
-		Logger: sugarLogger,
+		Config:         &v1alpha1.ConfigSpec{},
+		Logger:         sugarLogger,
+		StateDirectory: stateDirFlag,
+		Clock:          clock.SystemClock{},
 	}
 
 	// Get and parse the config
@@ -103,6 +133,11 @@ func RunCommand(cmd *cobra.Command, args []string) {
 	// Set the configuration inside the global context
 	ctx.Config = &configContent
 
+	// Cancel everything when the process is requested to finish, i.e. on Kubernetes rollouts
+	signalContext, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx.Context = signalContext
+
 	//
 	schedules.RunScheduler(&ctx)
 	//select {}
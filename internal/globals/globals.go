@@ -1,10 +1,21 @@
 package globals
 
 import (
+	"context"
 	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock"
 )
 
 func Retry(function func() error, attempts int, timeBetweenAttempts time.Duration) (err error) {
+	return RetryContext(context.Background(), clock.SystemClock{}, function, attempts, timeBetweenAttempts)
+}
+
+// RetryContext execute the function until it succeeds or the attempts are exhausted, like Retry.
+// Waits between attempts are measured by the given clock, and interrupted when the given context is cancelled,
+// returning its error
+func RetryContext(ctx context.Context, clk v1alpha1.Clock, function func() error, attempts int, timeBetweenAttempts time.Duration) (err error) {
 
 	var functionError error
 
@@ -14,7 +25,9 @@ func Retry(function func() error, attempts int, timeBetweenAttempts time.Duratio
 			break
 		}
 
-		time.Sleep(timeBetweenAttempts)
+		if !clk.Sleep(ctx, timeBetweenAttempts) {
+			return ctx.Err()
+		}
 	}
 
 	if functionError != nil {
@@ -0,0 +1,74 @@
+// ATTENTION:
+// Price and weather providers retrieve their data from HTTP APIs in the same way, so requests are sent here.
+// Clients are taken from the context, so tests can point them to fake servers
+
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	//
+	HttpRequestFailedErrorMessage      = "error performing http request: %s"
+	HttpResponseReadFailedErrorMessage = "error reading http response's body: %s"
+	HttpResponseStatusErrorMessage     = "unexpected status code in http response: %d"
+)
+
+// StatusError represents a response whose status code is not 200.
+// Its body is kept, as some APIs explain there the reason of the failure
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Error return the message of the error
+func (e *StatusError) Error() string {
+	return fmt.Sprintf(HttpResponseStatusErrorMessage, e.StatusCode)
+}
+
+// GetClient return the client defined on the context to perform the requests, or the default one
+func GetClient(ctx *v1alpha1.Context) *http.Client {
+
+	if ctx.HttpClient == nil {
+		return http.DefaultClient
+	}
+
+	return ctx.HttpClient
+}
+
+// Do send the given request and return the body of the response.
+// Responses with status codes different from 200, including the ones without content, are returned as StatusError
+func Do(httpClient *http.Client, httpRequest *http.Request) (body []byte, err error) {
+
+	// Send the request and wait for the result
+	resp, err := httpClient.Do(httpRequest)
+	if err != nil {
+		return body, errors.New(fmt.Sprintf(HttpRequestFailedErrorMessage, err))
+	}
+	defer resp.Body.Close()
+
+	// Read the request's body
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return body, errors.New(fmt.Sprintf(HttpResponseReadFailedErrorMessage, err))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+
+	return body, nil
+}
+
+// IsStatus return whether the given error is a StatusError with the given status code
+func IsStatus(err error, statusCode int) bool {
+
+	var statusError *StatusError
+	return errors.As(err, &statusError) && statusError.StatusCode == statusCode
+}
@@ -0,0 +1,51 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+
+	tests := map[string]struct {
+		statusCode   int
+		expectedBody string
+	}{
+		"successful response":      {statusCode: http.StatusOK, expectedBody: "prices"},
+		"response without content": {statusCode: http.StatusNoContent},
+		"rejected request":         {statusCode: http.StatusBadRequest, expectedBody: "reason"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.expectedBody))
+			}))
+			t.Cleanup(server.Close)
+
+			httpRequest, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := Do(server.Client(), httpRequest)
+			if test.statusCode == http.StatusOK {
+				if err != nil || string(body) != test.expectedBody {
+					t.Fatalf("expected the body '%s', got '%s' and the error '%v'", test.expectedBody, body, err)
+				}
+				return
+			}
+
+			// Bodies of failed responses are kept in the error
+			if !IsStatus(err, test.statusCode) {
+				t.Fatalf("expected an error with the status code %d, got '%v'", test.statusCode, err)
+			}
+
+			if string(err.(*StatusError).Body) != test.expectedBody {
+				t.Errorf("expected the body '%s' in the error, got '%s'", test.expectedBody, err.(*StatusError).Body)
+			}
+		})
+	}
+}
@@ -17,11 +17,14 @@ const (
 	RequiredConfigFieldsMissingMessage = "some mandatory config field is missing on Tapo smartplug integration"
 
 	// Error messages
-	TurningOffDuringRetriesError = "error turning off tapo smartplug device (retries left?): %s"
-	TurningOnDuringRetriesError  = "error turning on tapo smartplug device (retries left?): %s"
-	TurningOffError              = "error turning off tapo smartplug device: %s"
-	TurningOnError               = "error turning on tapo smartPlug device: %s"
-	ClientCreationError          = "tapo client failed on creation: %s"
+	TurningOffDuringRetriesError   = "error turning off tapo smartplug device (retries left?): %s"
+	TurningOnDuringRetriesError    = "error turning on tapo smartplug device (retries left?): %s"
+	TurningOffError                = "error turning off tapo smartplug device: %s"
+	TurningOnError                 = "error turning on tapo smartPlug device: %s"
+	ClientCreationError            = "tapo client failed on creation: %s"
+	GettingStateDuringRetriesError = "error getting state of tapo smartplug device (retries left?): %s"
+	GettingStateError              = "error getting state of tapo smartplug device: %s"
+	StateNotFoundError             = "state of tapo smartplug device not found on its response"
 
 	// Default values
 	RequestRetryAttempts      = 10
@@ -180,3 +183,90 @@ func TurnOffDevice(ctx *v1alpha1.Context) (tapoResponse map[string]interface{},
 
 	return tapoResponse, err
 }
+
+// IsDeviceOn send a request to tapo API to know whether the device is turned on.
+// Retries are interrupted when the context is cancelled, so they never delay the shutdown
+func IsDeviceOn(ctx *v1alpha1.Context) (deviceOn bool, err error) {
+
+	err = checkConfigFields(ctx)
+	if err != nil {
+		return deviceOn, err
+	}
+
+	//
+	tapoConfig := ctx.Config.Spec.Device.Integrations.TapoSmartPlug
+
+	switch tapoConfig.Client {
+	case "legacy":
+		var tapoResponse map[string]interface{}
+		err = globals.RetryContext(ctx.Context, ctx.Clock,
+			func() (err error) {
+				tapoClient, err := tapo.NewTapo(tapoConfig.Address, tapoConfig.Auth.Username, tapoConfig.Auth.Password)
+				if err != nil {
+					ctx.Logger.Errorf(ClientCreationError, err)
+					return err
+				}
+
+				tapoResponse, err = tapoClient.DeviceInfo()
+				if err != nil {
+					ctx.Logger.Errorf(GettingStateDuringRetriesError, err)
+				}
+				return err
+			},
+			RequestRetryAttempts,
+			RequestRetryDelayDuration)
+
+		if err != nil {
+			ctx.Logger.Errorf(GettingStateError, err)
+			return deviceOn, err
+		}
+
+		result, resultFound := tapoResponse["result"].(map[string]interface{})
+		if !resultFound {
+			return deviceOn, errors.New(StateNotFoundError)
+		}
+
+		deviceOn, deviceOnFound := result["device_on"].(bool)
+		if !deviceOnFound {
+			return deviceOn, errors.New(StateNotFoundError)
+		}
+
+		return deviceOn, nil
+
+	default:
+		// New KLAP protocol throws random errors when the requests are done at speed.
+		// Retrying with a new token mostly solve the issue (jaquecoso...)
+		tapoResponseNew := &tapogotypes.ResponseSpec{}
+		err = globals.RetryContext(ctx.Context, ctx.Clock,
+			func() (err error) {
+				tapoClientNew, err := tapogo.NewTapo(tapoConfig.Address,
+					tapoConfig.Auth.Username,
+					tapoConfig.Auth.Password,
+					&tapogo.TapoOptions{})
+
+				if err != nil {
+					ctx.Logger.Errorf(ClientCreationError, err)
+					return err
+				}
+
+				tapoResponseNew, err = tapoClientNew.DeviceInfo()
+				if err != nil {
+					ctx.Logger.Errorf(GettingStateDuringRetriesError, err)
+				}
+				return err
+			},
+			RequestRetryAttempts,
+			RequestRetryDelayDuration)
+
+		if err != nil {
+			ctx.Logger.Errorf(GettingStateError, err)
+			return deviceOn, err
+		}
+
+		if tapoResponseNew == nil || tapoResponseNew.Result == nil {
+			return deviceOn, errors.New(StateNotFoundError)
+		}
+
+		return tapoResponseNew.Result.DeviceOn, nil
+	}
+}
@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,15 +13,30 @@ import (
 )
 
 const (
-	HttpEventPattern = `{"event":"%s","name":"%s","timestamp":"%s"}`
-	HttpEventVerb    = "POST"
+	HttpEventPattern     = `{"event":"%s","name":"%s","timestamp":"%s"}`
+	HttpPlanEventPattern = `{"event":"plan","name":"%s","timestamp":"%s","plan":%s}`
+	HttpEventVerb        = "POST"
 
 	HttpRequestCreationErrorMessage = "error creating http request: %s"
 	HttpRequestSendingErrorMessage  = "error sending http request: %s"
+	PlanEncodingErrorMessage        = "error encoding plan: %s"
 
 	RequiredConfigFieldsMissingMessage = "some mandatory config field is missing on webhooks integration"
 )
 
+// PlanSpec represents the content of 'plan' events
+type PlanSpec struct {
+	Schedules  []PlanScheduleSpec `json:"schedules"`
+	Estimation interface{}        `json:"estimation"`
+}
+
+// PlanScheduleSpec represents each range of time when the device will be turned on
+type PlanScheduleSpec struct {
+	Start   time.Time `json:"start"`
+	Stop    time.Time `json:"stop"`
+	Reasons []string  `json:"reasons"`
+}
+
 // --
 func checkConfigFields(ctx *v1alpha1.Context) (err error) {
 	webhookConfig := ctx.Config.Spec.Device.Integrations.Webhook
@@ -35,8 +51,17 @@ func checkConfigFields(ctx *v1alpha1.Context) (err error) {
 
 // sendEvent send an HTTP request with the content '{"event":"%s","name":"%s","timestamp":"%s"}'
 func sendEvent(ctx *v1alpha1.Context, event string) (httpResponse *http.Response, err error) {
-	//
-	httpClient := &http.Client{}
+	payload := []byte(fmt.Sprintf(HttpEventPattern, event, ctx.Config.Metadata.Name, ctx.Clock.Now().In(time.Local)))
+	return sendPayload(ctx, payload)
+}
+
+// sendPayload send an HTTP request with the given content
+func sendPayload(ctx *v1alpha1.Context, payload []byte) (httpResponse *http.Response, err error) {
+	// Client defined on the context is used when present, i.e. in tests
+	httpClient := ctx.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
 
 	webhookConfig := ctx.Config.Spec.Device.Integrations.Webhook
 
@@ -52,7 +77,6 @@ func sendEvent(ctx *v1alpha1.Context, event string) (httpResponse *http.Response
 	}
 
 	// Add data to the request
-	payload := []byte(fmt.Sprintf(HttpEventPattern, event, ctx.Config.Metadata.Name, time.Now().In(time.Local)))
 	httpRequest.Body = io.NopCloser(bytes.NewBuffer(payload))
 	httpRequest.Header.Set("Content-Type", "application/json")
 
@@ -92,3 +116,22 @@ func SendStopDeviceEvent(ctx *v1alpha1.Context) (httpResponse *http.Response, er
 	httpResponse, err = sendEvent(ctx, "stop")
 	return httpResponse, err
 }
+
+// SendPlanEvent send a request with the schedules of a new plan and their estimated cost
+func SendPlanEvent(ctx *v1alpha1.Context, plan PlanSpec) (httpResponse *http.Response, err error) {
+
+	err = checkConfigFields(ctx)
+	if err != nil {
+		return httpResponse, err
+	}
+
+	planBytes, err := json.Marshal(plan)
+	if err != nil {
+		return httpResponse, errors.New(fmt.Sprintf(PlanEncodingErrorMessage, err))
+	}
+
+	//
+	payload := []byte(fmt.Sprintf(HttpPlanEventPattern, ctx.Config.Metadata.Name, ctx.Clock.Now().In(time.Local), planBytes))
+	httpResponse, err = sendPayload(ctx, payload)
+	return httpResponse, err
+}
@@ -0,0 +1,134 @@
+// ATTENTION:
+// Some datasources are defined by the user on config: a file or an HTTP endpoint, and a mapping telling where
+// the values are inside the document. This package holds the logic shared by all of them to read those documents
+
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Special values for 'timestampLayout' fields
+	TimestampLayoutUnix      = "unix"
+	TimestampLayoutUnixMilli = "unixMilli"
+
+	//
+	NumberParsingErrorMessage        = "impossible to parse the number '%v'"
+	TimezoneNotSupportedErrorMessage = "impossible to load timezone '%s': %s"
+)
+
+// LoadLocation return the timezone with the given name. Timezone of the system is used by default
+func LoadLocation(timezone string) (location *time.Location, err error) {
+
+	if timezone == "" {
+		return time.Local, nil
+	}
+
+	location, err = time.LoadLocation(timezone)
+	if err != nil {
+		return location, errors.New(fmt.Sprintf(TimezoneNotSupportedErrorMessage, timezone, err))
+	}
+
+	return location, nil
+}
+
+// LookupPath return the value found on a decoded JSON document following a dot-path. i.e: data.prices.0.value
+// JSONPath root prefix '$' is accepted and ignored. Empty paths return the document itself
+func LookupPath(document interface{}, path string) (value interface{}, found bool) {
+
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return document, true
+	}
+
+	value = document
+	for _, key := range strings.Split(path, ".") {
+
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			value, found = typedValue[key]
+			if !found {
+				return nil, false
+			}
+
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(typedValue) {
+				return nil, false
+			}
+			value = typedValue[index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// ParseTimestamp return the moment represented by a raw timestamp.
+// Layouts 'unix' and 'unixMilli' are used for epoch timestamps. Any other value is used as Go time layout,
+// and RFC3339 is used by default
+func ParseTimestamp(value interface{}, layout string, location *time.Location) (instant time.Time, err error) {
+
+	switch layout {
+	case TimestampLayoutUnix, TimestampLayoutUnixMilli:
+		epoch, err := ParseNumber(value)
+		if err != nil {
+			return instant, err
+		}
+
+		if layout == TimestampLayoutUnix {
+			return time.Unix(int64(epoch), 0), nil
+		}
+		return time.UnixMilli(int64(epoch)), nil
+
+	case "":
+		layout = time.RFC3339
+	}
+
+	return time.ParseInLocation(layout, fmt.Sprint(value), location)
+}
+
+// DisambiguateInstant return the first occurrence of the wall clock of the given moment after the previous one
+// of a sequence. Timestamps without offset are ambiguous during the hour repeated by DST changes, and the occurrence
+// chosen when parsing them is not guaranteed, so both of them would end being the same moment otherwise
+func DisambiguateInstant(instant time.Time, previous time.Time) time.Time {
+
+	wallClock := instant.Format(time.DateTime)
+
+	// Occurrences are looked for around the moment, as offsets change up to one hour
+	var occurrences []time.Time
+	for shift := -2 * time.Hour; shift <= 2*time.Hour; shift += 30 * time.Minute {
+		occurrence := instant.Add(shift)
+		if occurrence.Format(time.DateTime) == wallClock {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	for _, occurrence := range occurrences {
+		if previous.IsZero() || occurrence.After(previous) {
+			return occurrence
+		}
+	}
+
+	return instant
+}
+
+// ParseNumber return the float represented by a raw JSON or CSV value
+func ParseNumber(value interface{}) (float64, error) {
+
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(strings.ReplaceAll(typedValue, ",", ".")), 64)
+	}
+
+	return 0, errors.New(fmt.Sprintf(NumberParsingErrorMessage, value))
+}
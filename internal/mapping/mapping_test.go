@@ -0,0 +1,241 @@
+package mapping
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestLookupPath(t *testing.T) {
+
+	var document interface{}
+	err := json.Unmarshal([]byte(`{"data": {"prices": [{"value": 1.5}, {"value": "2,5"}], "empty": null}}`), &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		path          string
+		expected      interface{}
+		expectedFound bool
+	}{
+		"nested field": {
+			path:          "data.prices.0.value",
+			expected:      1.5,
+			expectedFound: true,
+		},
+		"jsonpath root prefix": {
+			path:          "$.data.prices.1.value",
+			expected:      "2,5",
+			expectedFound: true,
+		},
+		"null field": {
+			path:          "data.empty",
+			expected:      nil,
+			expectedFound: true,
+		},
+		"missing field": {
+			path: "data.missing",
+		},
+		"index out of the list": {
+			path: "data.prices.2.value",
+		},
+		"index that is not a number": {
+			path: "data.prices.first",
+		},
+		"field inside a scalar": {
+			path: "data.prices.0.value.amount",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			value, found := LookupPath(document, test.path)
+			if found != test.expectedFound || value != test.expected {
+				t.Errorf("expected %v (found %t), got %v (found %t)", test.expected, test.expectedFound, value, found)
+			}
+		})
+	}
+
+	// Empty paths return the document itself
+	if value, found := LookupPath(document, "$"); !found || value == nil {
+		t.Errorf("expected the document for the root path, got %v", value)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+
+	tests := map[string]struct {
+		value    interface{}
+		expected float64
+
+		// Some values are not numbers
+		expectError bool
+	}{
+		"json number": {
+			value:    0.125,
+			expected: 0.125,
+		},
+		"string with a dot": {
+			value:    "0.125",
+			expected: 0.125,
+		},
+		"string with a decimal comma and spaces": {
+			value:    " 0,125 ",
+			expected: 0.125,
+		},
+		"text": {
+			value:       "cheap",
+			expectError: true,
+		},
+		"boolean": {
+			value:       true,
+			expectError: true,
+		},
+		"null": {
+			value:       nil,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			number, err := ParseNumber(test.value)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %g", number)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if number != test.expected {
+				t.Errorf("expected %g, got %g", test.expected, number)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+
+	location, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Date(2026, time.January, 12, 10, 0, 0, 0, location)
+
+	tests := map[string]struct {
+		value  interface{}
+		layout string
+
+		// Some values do not match the layout
+		expectError bool
+	}{
+		"rfc3339 by default": {
+			value: "2026-01-12T09:00:00Z",
+		},
+		"unix seconds as json number": {
+			value:  float64(expected.Unix()),
+			layout: TimestampLayoutUnix,
+		},
+		"unix milliseconds as string": {
+			value:  "1768208400000",
+			layout: TimestampLayoutUnixMilli,
+		},
+		"go layout without offset in the given location": {
+			value:  "2026-01-12 10:00",
+			layout: "2006-01-02 15:04",
+		},
+		"value not matching the layout": {
+			value:       "12/01/2026 10:00",
+			layout:      "2006-01-02 15:04",
+			expectError: true,
+		},
+		"unix timestamp that is not a number": {
+			value:       "yesterday",
+			layout:      TimestampLayoutUnix,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			instant, err := ParseTimestamp(test.value, test.layout, location)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", instant)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !instant.Equal(expected) {
+				t.Errorf("expected %s, got %s", expected, instant)
+			}
+		})
+	}
+}
+
+func TestDisambiguateInstant(t *testing.T) {
+
+	location, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// On the last Sunday of October, 02:00 CEST goes back to 02:00 CET, so 02:xx happens twice
+	firstOccurrence := time.Date(2026, time.October, 25, 0, 0, 0, 0, time.UTC).In(location)
+	secondOccurrence := firstOccurrence.Add(time.Hour)
+
+	if firstOccurrence.Hour() != 2 || secondOccurrence.Hour() != 2 {
+		t.Fatalf("expected both occurrences at 02:00, got %s and %s", firstOccurrence, secondOccurrence)
+	}
+
+	tests := map[string]struct {
+		instant  time.Time
+		previous time.Time
+		expected time.Time
+	}{
+		"first moment of a sequence": {
+			instant:  firstOccurrence,
+			expected: firstOccurrence,
+		},
+		"repeated hour after the first occurrence": {
+			instant:  firstOccurrence,
+			previous: firstOccurrence,
+			expected: secondOccurrence,
+		},
+		"repeated hour parsed as the second occurrence after the hour before": {
+			instant:  secondOccurrence,
+			previous: firstOccurrence.Add(-time.Hour),
+			expected: firstOccurrence,
+		},
+		"hour after the repeated one": {
+			instant:  secondOccurrence.Add(time.Hour),
+			previous: secondOccurrence,
+			expected: secondOccurrence.Add(time.Hour),
+		},
+		"ordinary hour": {
+			instant:  time.Date(2026, time.January, 12, 10, 0, 0, 0, location),
+			previous: time.Date(2026, time.January, 12, 9, 0, 0, 0, location),
+			expected: time.Date(2026, time.January, 12, 10, 0, 0, 0, location),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			instant := DisambiguateInstant(test.instant, test.previous)
+			if !instant.Equal(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, instant)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// ATTENTION:
+// ApagaLuz is a datasource for PVPC. This datasource uses official ESIOS' API from the spanish
+// government, which return data using CEST timezone. As a consequence, this provider works using CEST timezone
+// and the other packages must take this into account to convert it and do whatever they need.
+// [ESIOS] Ref: https://www.esios.ree.es/es/pvpc
+
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	ApagaLuzAPIUrl       = "https://raw.githubusercontent.com/jorgeatgu/apaga-luz/main/public/data/today_price.json"
+	ApagaLuzCanaryAPIUrl = "https://raw.githubusercontent.com/jorgeatgu/apaga-luz/main/public/data/canary_price.json"
+
+	// Location of retrieved data from ApagaLuz
+	ApagaLuzApiTimeLocation       = "Europe/Madrid"
+	ApagaLuzCanaryApiTimeLocation = "Atlantic/Canary"
+
+	//
+	apagaLuzDateLayout = "02/01/2006 15"
+
+	//
+	ApagaLuzHttpRequestFailedErrorMessage = "error performing http request to ApagaLuz: %s"
+	ApagaLuzHttpResponseErrorMessage      = "error decoding ApagaLuz response: %s"
+)
+
+// ApagaLuzHourDataSpec represents each of the individual items in the response retrieved from ApagaLuz API
+type ApagaLuzHourDataSpec struct {
+	Day   string  `json:"day"`
+	Hour  int     `json:"hour"`
+	Price float64 `json:"price"`
+	Zone  string  `json:"zone"`
+}
+
+// ApagaLuzProvider represents a price provider that retrieves today's PVPC prices from ApagaLuz
+type ApagaLuzProvider struct {
+	url        string
+	httpClient *http.Client
+	location   *time.Location
+}
+
+// NewApagaLuzProvider return an ApagaLuz provider configured for the zone defined on 'price.apagaluz.zone',
+// or on 'price.zone' when the provider does not define its own
+func NewApagaLuzProvider(ctx *v1alpha1.Context) (provider *ApagaLuzProvider, err error) {
+
+	provider = &ApagaLuzProvider{
+		httpClient: httpx.GetClient(ctx),
+		url:        ApagaLuzAPIUrl,
+	}
+
+	apiTimeLocation := ApagaLuzApiTimeLocation
+	if GetProviderZone(ctx, ProviderApagaLuz) == "canaryislands" {
+		provider.url = ApagaLuzCanaryAPIUrl
+		apiTimeLocation = ApagaLuzCanaryApiTimeLocation
+	}
+
+	if ctx.Config.Spec.Price.ApagaLuz.URL != "" {
+		provider.url = ctx.Config.Spec.Price.ApagaLuz.URL
+	}
+
+	provider.location, err = time.LoadLocation(apiTimeLocation)
+	return provider, err
+}
+
+// Location return the timezone used by ApagaLuz to express the prices
+func (p *ApagaLuzProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the time covered by each price returned by ApagaLuz
+func (p *ApagaLuzProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+// GetPrices return the prices for the hours starting in the range [start, end).
+// ApagaLuz only publishes today's prices, so hours from other days are never returned
+func (p *ApagaLuzProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	httpRequest, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(ApagaLuzHttpRequestFailedErrorMessage, err))
+	}
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(ApagaLuzHttpRequestFailedErrorMessage, err))
+	}
+
+	// Decode response's JSON into a struct
+	var response []ApagaLuzHourDataSpec
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(ApagaLuzHttpResponseErrorMessage, err))
+	}
+
+	// Hours of the same day are consecutive, so only the first one is parsed. Days when DST changes have
+	// 23 or 25 hours, and their hour numbers are missing or repeated, so they can not be parsed one by one
+	var itemTime time.Time
+	var itemDay string
+
+	// Keep only the hours inside the requested range
+	for _, item := range response {
+		if item.Day == itemDay {
+			itemTime = itemTime.Add(time.Hour)
+		} else {
+			itemTime, err = time.ParseInLocation(apagaLuzDateLayout, fmt.Sprintf("%s %d", item.Day, item.Hour), p.location)
+			if err != nil {
+				return prices, errors.New(fmt.Sprintf(ApagaLuzHttpResponseErrorMessage, err))
+			}
+			itemDay = item.Day
+		}
+
+		if itemTime.Before(start) || !itemTime.Before(end) {
+			continue
+		}
+
+		prices = append(prices, NewSlot(itemTime, time.Hour, item.Price, item.Zone))
+	}
+
+	return prices, nil
+}
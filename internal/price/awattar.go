@@ -0,0 +1,137 @@
+// ATTENTION:
+// aWATTar publishes the EPEX spot day-ahead prices for Austria and Germany. No token is required
+// [aWATTar] Ref: https://www.awattar.at/services/api
+
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	AwattarAustriaAPIUrl = "https://api.awattar.at/v1/marketdata"
+	AwattarGermanyAPIUrl = "https://api.awattar.de/v1/marketdata"
+
+	//
+	AwattarCountryNotSupportedErrorMessage  = "zone '%s' is not supported by aWATTar provider. Possible values: AT, DE"
+	AwattarHttpRequestFailedErrorMessage    = "error performing http request to aWATTar: %s"
+	AwattarHttpResponseDecodingErrorMessage = "error decoding aWATTar response: %s"
+)
+
+// AwattarResponseSpec represents the fields returned by aWATTar marketdata endpoint
+type AwattarResponseSpec struct {
+	Object string `json:"object"`
+	Data   []struct {
+		StartTimestamp int64   `json:"start_timestamp"`
+		EndTimestamp   int64   `json:"end_timestamp"`
+		MarketPrice    float64 `json:"marketprice"`
+		Unit           string  `json:"unit"`
+	} `json:"data"`
+}
+
+// AwattarProvider represents a price provider that retrieves day-ahead prices from aWATTar
+type AwattarProvider struct {
+	url        string
+	httpClient *http.Client
+	country    string
+	location   *time.Location
+}
+
+// NewAwattarProvider return an aWATTar provider configured for the country defined on 'price.awattar.zone',
+// or on 'price.zone' when the provider does not define its own
+func NewAwattarProvider(ctx *v1alpha1.Context) (provider *AwattarProvider, err error) {
+
+	awattarConfig := ctx.Config.Spec.Price.Awattar
+	zone := GetProviderZone(ctx, ProviderAwattar)
+
+	provider = &AwattarProvider{
+		httpClient: httpx.GetClient(ctx),
+		country:    strings.ToUpper(zone),
+	}
+
+	apiTimeLocation := ""
+	switch provider.country {
+	case "AT":
+		provider.url = AwattarAustriaAPIUrl
+		apiTimeLocation = "Europe/Vienna"
+	case "DE":
+		provider.url = AwattarGermanyAPIUrl
+		apiTimeLocation = "Europe/Berlin"
+	default:
+		return provider, errors.New(fmt.Sprintf(AwattarCountryNotSupportedErrorMessage, zone))
+	}
+
+	if awattarConfig.URL != "" {
+		provider.url = awattarConfig.URL
+	}
+
+	provider.location, err = time.LoadLocation(apiTimeLocation)
+	return provider, err
+}
+
+// Location return the timezone of the configured country
+func (p *AwattarProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the nominal time covered by each price returned by the provider
+func (p *AwattarProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end)
+func (p *AwattarProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	// Encode everything as URL. Timestamps are expressed in milliseconds
+	params := url.Values{}
+	params.Add("start", strconv.FormatInt(start.UnixMilli(), 10))
+	params.Add("end", strconv.FormatInt(end.UnixMilli(), 10))
+
+	requestUrl, err := url.Parse(p.url)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(AwattarHttpRequestFailedErrorMessage, err))
+	}
+	requestUrl.RawQuery = params.Encode()
+
+	httpRequest, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(AwattarHttpRequestFailedErrorMessage, err))
+	}
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(AwattarHttpRequestFailedErrorMessage, err))
+	}
+
+	// Decode response's JSON into a struct
+	response := AwattarResponseSpec{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(AwattarHttpResponseDecodingErrorMessage, err))
+	}
+
+	for _, item := range response.Data {
+		itemTime := time.UnixMilli(item.StartTimestamp)
+
+		if itemTime.Before(start) || !itemTime.Before(end) {
+			continue
+		}
+
+		// aWATTar express the prices in Eur/MWh
+		itemDuration := time.UnixMilli(item.EndTimestamp).Sub(itemTime)
+		prices = append(prices, NewSlot(itemTime.In(p.location), itemDuration, item.MarketPrice/1000, p.country))
+	}
+
+	SortByTime(prices)
+	return prices, nil
+}
@@ -0,0 +1,101 @@
+package price
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAwattarPrices(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+
+	// The fixture publishes the whole day, plus the hour before, to check that the requested range is applied
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") != strconv.FormatInt(start.UnixMilli(), 10) ||
+			r.URL.Query().Get("end") != strconv.FormatInt(end.UnixMilli(), 10) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		response := AwattarResponseSpec{Object: "list"}
+		for instant := end.Add(-time.Hour); !instant.Before(start.Add(-time.Hour)); instant = instant.Add(-time.Hour) {
+			response.Data = append(response.Data, struct {
+				StartTimestamp int64   `json:"start_timestamp"`
+				EndTimestamp   int64   `json:"end_timestamp"`
+				MarketPrice    float64 `json:"marketprice"`
+				Unit           string  `json:"unit"`
+			}{
+				StartTimestamp: instant.UnixMilli(),
+				EndTimestamp:   instant.Add(time.Hour).UnixMilli(),
+				MarketPrice:    float64(instant.Sub(start)/time.Hour) * 10,
+				Unit:           "Eur/MWh",
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		zone             string
+		expectedLocation string
+
+		// The zone may not be supported
+		expectError bool
+	}{
+		"austria": {
+			zone:             "AT",
+			expectedLocation: "Europe/Vienna",
+		},
+		"germany in lowercase": {
+			zone:             "de",
+			expectedLocation: "Europe/Berlin",
+		},
+		"unsupported zone": {
+			zone:        "ES",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Zone = test.zone
+			ctx.Config.Spec.Price.Awattar.URL = server.URL
+
+			provider, err := NewAwattarProvider(ctx)
+			if test.expectError {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if provider.Location().String() != test.expectedLocation {
+				t.Errorf("expected the location %s, got %s", test.expectedLocation, provider.Location())
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, start, end)
+
+			// Prices are converted from Eur/MWh into Eur/kWh
+			for index, item := range prices {
+				if expectedPrice := float64(index) / 100; item.Price != expectedPrice {
+					t.Errorf("expected the price %g for hour %d, got %g", expectedPrice, index, item.Price)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/state"
+)
+
+const (
+	//
+	cacheDayLayout = "2006-01-02"
+	cacheZoneEmpty = "default"
+
+	//
+	CacheReadFailedMessage  = "impossible to read prices from cache, requesting them to '%s' provider: %s"
+	CacheWriteFailedMessage = "impossible to store prices from '%s' provider in cache: %s"
+)
+
+// CachedPriceProvider represents a price provider that stores on disk every day successfully retrieved
+// from another provider, so they are not requested again.
+// Days are stored in files like <directory>/<provider>/<zone>/<date>.json
+type CachedPriceProvider struct {
+	ctx       *v1alpha1.Context
+	name      string
+	zone      string
+	directory string
+	provider  PriceProvider
+}
+
+// NewCachedPriceProvider return a provider that caches the prices of the given one on 'price.cache.directory'
+func NewCachedPriceProvider(ctx *v1alpha1.Context, name string, provider PriceProvider) *CachedPriceProvider {
+
+	zone := GetProviderZone(ctx, name)
+	if zone == "" {
+		zone = cacheZoneEmpty
+	}
+
+	return &CachedPriceProvider{
+		ctx:       ctx,
+		name:      name,
+		zone:      zone,
+		directory: ctx.Config.Spec.Price.Cache.Directory,
+		provider:  provider,
+	}
+}
+
+// Location return the timezone of the cached provider
+func (p *CachedPriceProvider) Location() *time.Location {
+	return p.provider.Location()
+}
+
+// Resolution return the time covered by each price returned by the cached provider
+func (p *CachedPriceProvider) Resolution() time.Duration {
+	return p.provider.Resolution()
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end).
+// Each day covered by the range is read from the cache, and requested to the provider only when it's missing
+func (p *CachedPriceProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	rangeStart := start.In(p.Location())
+	day := time.Date(rangeStart.Year(), rangeStart.Month(), rangeStart.Day(), 0, 0, 0, 0, p.Location())
+
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+
+		dayPrices, err := p.getDay(day)
+		if err != nil {
+			return prices, err
+		}
+
+		// Keep only the slots inside the requested range
+		for _, item := range dayPrices {
+			if item.Start.Before(start) || !item.Start.Before(end) {
+				continue
+			}
+
+			prices = append(prices, NewSlot(item.Start.In(p.Location()), item.Duration, item.Price, item.Zone))
+		}
+	}
+
+	return prices, nil
+}
+
+// getDay return the prices for the whole given day from the cache, or from the provider when missing.
+// Only complete days are stored, so partially published days are requested again next time
+func (p *CachedPriceProvider) getDay(day time.Time) (prices SlotList, err error) {
+
+	cacheFilePath := filepath.Join(p.directory, p.name, p.zone, day.Format(cacheDayLayout)+".json")
+
+	nextDay := day.AddDate(0, 0, 1)
+
+	// Files written by older versions store hours without duration, so they are requested again
+	cacheBytes, err := os.ReadFile(cacheFilePath)
+	if err == nil {
+		err = json.Unmarshal(cacheBytes, &prices)
+		if err == nil && CoversRange(prices, day, nextDay) {
+			return prices, nil
+		}
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		p.ctx.Logger.Infof(CacheReadFailedMessage, p.name, err)
+	}
+
+	prices, err = p.provider.GetPrices(day, nextDay)
+	if err != nil {
+		return prices, err
+	}
+
+	// Days with 23 or 25 hours are taken into account due to DST changes
+	if !CoversRange(prices, day, nextDay) {
+		return prices, nil
+	}
+
+	err = p.storeDay(cacheFilePath, prices)
+	if err != nil {
+		p.ctx.Logger.Infof(CacheWriteFailedMessage, p.name, err)
+	}
+
+	return prices, nil
+}
+
+// storeDay write the prices of a day into the given cache file
+func (p *CachedPriceProvider) storeDay(cacheFilePath string, prices SlotList) (err error) {
+
+	cacheBytes, err := json.Marshal(prices)
+	if err != nil {
+		return err
+	}
+
+	return state.WriteFile(cacheFilePath, cacheBytes)
+}
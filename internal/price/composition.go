@@ -0,0 +1,109 @@
+package price
+
+import (
+	"errors"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Possible values for 'price.composition.thresholdsBasis'
+	PriceBasisRaw   = "raw"
+	PriceBasisFinal = "final"
+
+	//
+	CompositionBasisNotSupportedErrorMessage = "config.price.composition.thresholdsBasis field must be one of: raw, final"
+)
+
+// ComposedPriceProvider represents a price provider that adds taxes, tolls and surcharges to the prices
+// of another provider. Raw prices are kept as they are, and the final ones are filled
+type ComposedPriceProvider struct {
+	composition v1alpha1.PriceCompositionSpec
+	provider    PriceProvider
+
+	// Calendar of the Spanish 2.0TD periods, only present when tolls are defined
+	calendar *TouProvider
+}
+
+// NewComposedPriceProvider return a provider that applies 'price.composition' to the prices of the given one.
+// Tolls depend on the 2.0TD period of each slot, so the calendar of the Spanish zone on 'price.composition.zone'
+// is used when they are defined. Tariffs from other countries only use the taxes and the margin
+func NewComposedPriceProvider(ctx *v1alpha1.Context, provider PriceProvider) (composedProvider *ComposedPriceProvider, err error) {
+
+	compositionConfig := ctx.Config.Spec.Price.Composition
+
+	composedProvider = &ComposedPriceProvider{
+		composition: compositionConfig,
+		provider:    provider,
+	}
+
+	if compositionConfig.Tolls == (v1alpha1.TouPeriodsSpec{}) {
+		return composedProvider, nil
+	}
+
+	zone := compositionConfig.Zone
+	if zone == "" {
+		zone = "mainland"
+	}
+
+	composedProvider.calendar, err = newTouCalendar(zone, compositionConfig.Holidays)
+	return composedProvider, err
+}
+
+// GetThresholdsBasis return the price compared against the thresholds, defined on 'price.composition.thresholdsBasis'.
+// Final price is used by default, which is the same as the raw one when no composition is defined
+func GetThresholdsBasis(ctx *v1alpha1.Context) (basis string, err error) {
+
+	basis = ctx.Config.Spec.Price.Composition.ThresholdsBasis
+
+	switch basis {
+	case "":
+		basis = PriceBasisFinal
+	case PriceBasisRaw, PriceBasisFinal:
+	default:
+		return basis, errors.New(CompositionBasisNotSupportedErrorMessage)
+	}
+
+	return basis, nil
+}
+
+// Location return the timezone of the composed provider
+func (p *ComposedPriceProvider) Location() *time.Location {
+	return p.provider.Location()
+}
+
+// Resolution return the time covered by each price returned by the composed provider
+func (p *ComposedPriceProvider) Resolution() time.Duration {
+	return p.provider.Resolution()
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end), with their final prices filled.
+// Final price is: (raw price + tolls of the period + margin) * (1 + electricity tax) * (1 + VAT)
+func (p *ComposedPriceProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	prices, err = p.provider.GetPrices(start, end)
+	if err != nil {
+		return prices, err
+	}
+
+	tolls := map[string]float64{
+		TouPeriodPunta: p.composition.Tolls.Punta,
+		TouPeriodLlano: p.composition.Tolls.Llano,
+		TouPeriodValle: p.composition.Tolls.Valle,
+	}
+
+	for index, item := range prices {
+		finalPrice := item.Price + p.composition.Margin
+		if p.calendar != nil {
+			finalPrice += tolls[p.calendar.GetPeriod(item.Start)]
+		}
+
+		finalPrice *= 1 + p.composition.ElectricityTax/100
+		finalPrice *= 1 + p.composition.Vat/100
+
+		prices[index].FinalPrice = finalPrice
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,144 @@
+package price
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+func TestComposedPriceProvider(t *testing.T) {
+
+	// Raw prices are 24 for every hour. Midnight in GMT is 01:00 in Spain
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	tolls := v1alpha1.TouPeriodsSpec{Punta: 3, Llano: 2, Valle: 1}
+
+	tests := map[string]struct {
+		composition v1alpha1.PriceCompositionSpec
+
+		// Final prices expected for some hours, by their index
+		expected map[int]float64
+
+		// The zone of the tolls may not be supported
+		expectError bool
+	}{
+		"tolls of each 2.0TD period": {
+			composition: v1alpha1.PriceCompositionSpec{Margin: 0.5, Tolls: tolls, ElectricityTax: 10, Vat: 20},
+			expected: map[int]float64{
+				0: (24 + 1 + 0.5) * 1.1 * 1.2,
+				7: (24 + 2 + 0.5) * 1.1 * 1.2,
+				9: (24 + 3 + 0.5) * 1.1 * 1.2,
+			},
+		},
+		"tolls of the canary islands follow their local time": {
+			composition: v1alpha1.PriceCompositionSpec{Tolls: tolls, Zone: "canaryislands"},
+			expected: map[int]float64{
+				9:  24 + 2,
+				10: 24 + 3,
+			},
+		},
+		"configured holidays are valle": {
+			composition: v1alpha1.PriceCompositionSpec{Tolls: tolls, Holidays: []string{"2026-01-12"}},
+			expected: map[int]float64{
+				9: 24 + 1,
+			},
+		},
+		"taxes and margin without tolls": {
+			composition: v1alpha1.PriceCompositionSpec{Margin: 0.5, ElectricityTax: 10, Vat: 20, Zone: "DE"},
+			expected: map[int]float64{
+				0: (24 + 0.5) * 1.1 * 1.2,
+				9: (24 + 0.5) * 1.1 * 1.2,
+			},
+		},
+		"tolls with a zone not following the 2.0TD periods": {
+			composition: v1alpha1.PriceCompositionSpec{Tolls: tolls, Zone: "DE"},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Composition = test.composition
+
+			provider, err := NewComposedPriceProvider(ctx, &fakePriceProvider{hours: 24})
+			if test.expectError {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for index, expectedPrice := range test.expected {
+				if prices[index].Price != 24 {
+					t.Errorf("expected the raw price 24 for hour %d, got %g", index, prices[index].Price)
+				}
+
+				if math.Abs(prices[index].FinalPrice-expectedPrice) > 1e-9 {
+					t.Errorf("expected the final price %g for hour %d, got %g", expectedPrice, index, prices[index].FinalPrice)
+				}
+			}
+		})
+	}
+}
+
+func TestGetThresholdsBasis(t *testing.T) {
+
+	tests := map[string]struct {
+		basis    string
+		expected string
+
+		// The basis may not be supported
+		expectError bool
+	}{
+		"final by default": {
+			expected: PriceBasisFinal,
+		},
+		"raw": {
+			basis:    PriceBasisRaw,
+			expected: PriceBasisRaw,
+		},
+		"unsupported basis": {
+			basis:       "gross",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(time.Now())
+			ctx.Config.Spec.Price.Composition.ThresholdsBasis = test.basis
+
+			basis, err := GetThresholdsBasis(ctx)
+			if (err != nil) != test.expectError {
+				t.Fatalf("expected error %t, got '%v'", test.expectError, err)
+			}
+
+			if !test.expectError && basis != test.expected {
+				t.Errorf("expected the basis %s, got %s", test.expected, basis)
+			}
+
+			// Constraints use the same basis
+			constraints, err := GetConstraints(ctx)
+			if (err != nil) != test.expectError {
+				t.Fatalf("expected error %t getting the constraints, got '%v'", test.expectError, err)
+			}
+
+			if !test.expectError && constraints.ThresholdsBasis != test.expected {
+				t.Errorf("expected the constraints basis %s, got %s", test.expected, constraints.ThresholdsBasis)
+			}
+		})
+	}
+}
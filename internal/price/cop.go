@@ -0,0 +1,132 @@
+package price
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	//
+	CopNotAppliedMessage = "prices are not weighted by config.device.cop: %s"
+
+	//
+	CopCurveNotFoundErrorMessage        = "config.device.cop.curve field requires at least one point with a positive cop"
+	CopWeatherNotEnabledErrorMessage    = "config.device.cop requires config.weather to be enabled, as the cop depends on the temperature"
+	CopTemperaturesNotFoundErrorMessage = "no temperatures forecast for the slots"
+)
+
+// CopPoint represents the efficiency of the device at a given outdoor temperature
+type CopPoint struct {
+	Temperature float64
+	Cop         float64
+}
+
+// CopCurve represents the efficiency of the device depending on the outdoor temperature, sorted by temperature
+type CopCurve []CopPoint
+
+// Temperature represents the outdoor temperature expected from a moment on
+type Temperature struct {
+	Time  time.Time
+	Value float64
+}
+
+// CheckCop return an error when the efficiency defined on 'device.cop' is not valid.
+// It's checked on start, so a wrong curve is not discovered once the prices are weighted
+func CheckCop(ctx *v1alpha1.Context) (err error) {
+
+	if !ctx.Config.Spec.Device.Cop.Enabled {
+		return nil
+	}
+
+	if !ctx.Config.Spec.Weather.Enabled {
+		return errors.New(CopWeatherNotEnabledErrorMessage)
+	}
+
+	_, err = GetCopCurve(ctx)
+	return err
+}
+
+// GetCopCurve return the curve defined on 'device.cop.curve'
+func GetCopCurve(ctx *v1alpha1.Context) (curve CopCurve, err error) {
+
+	for _, pointConfig := range ctx.Config.Spec.Device.Cop.Curve {
+		if pointConfig.Cop <= 0 {
+			return curve, errors.New(CopCurveNotFoundErrorMessage)
+		}
+		curve = append(curve, CopPoint{Temperature: pointConfig.Temperature, Cop: pointConfig.Cop})
+	}
+
+	if len(curve) == 0 {
+		return curve, errors.New(CopCurveNotFoundErrorMessage)
+	}
+
+	sort.SliceStable(curve, func(i, j int) bool {
+		return curve[i].Temperature < curve[j].Temperature
+	})
+
+	return curve, nil
+}
+
+// At return the efficiency at the given temperature. It's interpolated linearly between the points of the curve,
+// and kept constant outside of them
+func (c CopCurve) At(temperature float64) float64 {
+
+	if temperature <= c[0].Temperature {
+		return c[0].Cop
+	}
+
+	for index := 1; index < len(c); index++ {
+		if temperature > c[index].Temperature {
+			continue
+		}
+
+		lower, upper := c[index-1], c[index]
+		if upper.Temperature == lower.Temperature {
+			return upper.Cop
+		}
+
+		ratio := (temperature - lower.Temperature) / (upper.Temperature - lower.Temperature)
+		return lower.Cop + ratio*(upper.Cop-lower.Cop)
+	}
+
+	return c[len(c)-1].Cop
+}
+
+// ApplyCopCurve set the efficiency of each slot from the temperature expected at the moment it starts,
+// so the optimiser compares the price of the heat delivered instead of the price of the energy consumed.
+// Temperatures must be sorted by time. Slots out of them take the closest temperature
+func ApplyCopCurve(ctx *v1alpha1.Context, prices SlotList, temperatures []Temperature) (err error) {
+
+	curve, err := GetCopCurve(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(temperatures) == 0 {
+		return errors.New(CopTemperaturesNotFoundErrorMessage)
+	}
+
+	for index := range prices {
+		prices[index].Efficiency = curve.At(temperatures[closestTemperature(temperatures, prices[index].Start)].Value)
+	}
+
+	return nil
+}
+
+// closestTemperature return the index of the temperature expected at the given moment, or the closest one.
+// Temperatures must be sorted by time
+func closestTemperature(temperatures []Temperature, moment time.Time) int {
+
+	position := sort.Search(len(temperatures), func(i int) bool {
+		return temperatures[i].Time.After(moment)
+	})
+
+	// Temperature starting before the moment is the expected one, unless the moment is before all of them
+	if position == 0 {
+		return 0
+	}
+	return position - 1
+}
@@ -0,0 +1,140 @@
+package price
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+// copCurveConfig represents a curve defined on config, with the points unsorted
+var copCurveConfig = []v1alpha1.CopPointSpec{
+	{Temperature: 7, Cop: 3.8},
+	{Temperature: -7, Cop: 2.2},
+	{Temperature: 2, Cop: 3.0},
+}
+
+func TestCopCurveAt(t *testing.T) {
+
+	ctx := newDstContext(time.Now())
+	ctx.Config.Spec.Device.Cop.Curve = copCurveConfig
+
+	curve, err := GetCopCurve(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		temperature float64
+		expected    float64
+	}{
+		"below the curve": {
+			temperature: -15,
+			expected:    2.2,
+		},
+		"first point": {
+			temperature: -7,
+			expected:    2.2,
+		},
+		"between two points": {
+			temperature: 4.5,
+			expected:    3.4,
+		},
+		"above the curve": {
+			temperature: 20,
+			expected:    3.8,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if cop := curve.At(test.temperature); math.Abs(cop-test.expected) > 1e-9 {
+				t.Errorf("expected the cop %g at %g degrees, got %g", test.expected, test.temperature, cop)
+			}
+		})
+	}
+}
+
+func TestCheckCop(t *testing.T) {
+
+	tests := map[string]struct {
+		enabled        bool
+		weatherEnabled bool
+		curve          []v1alpha1.CopPointSpec
+
+		expectError bool
+	}{
+		"cop disabled": {
+			curve: []v1alpha1.CopPointSpec{{Temperature: 7, Cop: 0}},
+		},
+		"valid curve": {
+			enabled:        true,
+			weatherEnabled: true,
+			curve:          copCurveConfig,
+		},
+		"weather disabled": {
+			enabled:     true,
+			curve:       copCurveConfig,
+			expectError: true,
+		},
+		"empty curve": {
+			enabled:        true,
+			weatherEnabled: true,
+			expectError:    true,
+		},
+		"point without a positive cop": {
+			enabled:        true,
+			weatherEnabled: true,
+			curve:          []v1alpha1.CopPointSpec{{Temperature: 7, Cop: 3.8}, {Temperature: 2, Cop: 0}},
+			expectError:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(time.Now())
+			ctx.Config.Spec.Device.Cop.Enabled = test.enabled
+			ctx.Config.Spec.Device.Cop.Curve = test.curve
+			ctx.Config.Spec.Weather.Enabled = test.weatherEnabled
+
+			if err := CheckCop(ctx); (err != nil) != test.expectError {
+				t.Errorf("expected error %t, got '%v'", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestApplyCopCurve(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	prices := getHourlySlots(start, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1)
+
+	// Temperatures are expected every two hours, from the second hour on
+	temperatures := []Temperature{
+		{Time: start.Add(time.Hour), Value: -7},
+		{Time: start.Add(3 * time.Hour), Value: 2},
+		{Time: start.Add(5 * time.Hour), Value: 7},
+	}
+
+	ctx := newDstContext(start)
+	ctx.Config.Spec.Device.Cop.Curve = copCurveConfig
+
+	err := ApplyCopCurve(ctx, prices, temperatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Slots before the temperatures take the first one, and the rest take the last one started
+	expected := []float64{2.2, 2.2, 2.2, 3.0, 3.0, 3.8}
+	for index, item := range prices {
+		if item.Efficiency != expected[index] {
+			t.Errorf("expected the efficiency %g for hour %d, got %g", expected[index], index, item.Efficiency)
+		}
+	}
+
+	// Prices are not weighted without temperatures
+	if err = ApplyCopCurve(ctx, getHourlySlots(start, 0.1), nil); err == nil {
+		t.Error("expected an error without temperatures, got none")
+	}
+}
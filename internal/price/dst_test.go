@@ -0,0 +1,184 @@
+package price
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+	"go.uber.org/zap"
+)
+
+// newDstContext return a context for the tests of the days when DST changes
+func newDstContext(day time.Time) *v1alpha1.Context {
+	return &v1alpha1.Context{
+		Config: &v1alpha1.ConfigSpec{},
+		Logger: zap.NewNop().Sugar(),
+		Clock:  clocktest.NewFakeClock(day),
+	}
+}
+
+// getDstDay return the midnight of the given date in Spain, and the midnight of the following day
+func getDstDay(t *testing.T, year int, month time.Month, day int) (start time.Time, end time.Time) {
+
+	location, err := time.LoadLocation(ApagaLuzApiTimeLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start = time.Date(year, month, day, 0, 0, 0, 0, location)
+	end = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+	return start, end
+}
+
+// assertConsecutiveHours check that the prices are consecutive hours covering the whole range
+func assertConsecutiveHours(t *testing.T, prices SlotList, start time.Time, end time.Time) {
+
+	expectedHours := int(end.Sub(start) / time.Hour)
+	if len(prices) != expectedHours {
+		t.Fatalf("expected %d hours, got %d", expectedHours, len(prices))
+	}
+
+	for index, item := range prices {
+		expectedStart := start.Add(time.Duration(index) * time.Hour)
+		if !item.Start.Equal(expectedStart) || item.Duration != time.Hour {
+			t.Errorf("expected hour %d starting at %s lasting 1h, got %s lasting %s",
+				index, expectedStart, item.Start, item.Duration)
+		}
+	}
+
+	if !CoversRange(prices, start, end) {
+		t.Errorf("expected prices covering from %s to %s", start, end)
+	}
+}
+
+// newApagaLuzServer return a fake ApagaLuz server publishing the hours of the given day by their wall clock,
+// so the repeated hour comes twice and the skipped one is missing, as the real one does
+func newApagaLuzServer(t *testing.T, start time.Time, end time.Time) *httptest.Server {
+
+	var response []ApagaLuzHourDataSpec
+	for instant := start; instant.Before(end); instant = instant.Add(time.Hour) {
+		response = append(response, ApagaLuzHourDataSpec{
+			Day:   instant.Format("02/01/2006"),
+			Hour:  instant.Hour(),
+			Price: float64(len(response)),
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestApagaLuzDstDays(t *testing.T) {
+
+	tests := map[string][3]int{
+		"spring forward with 23 hours": {2026, int(time.March), 29},
+		"fall back with 25 hours":      {2026, int(time.October), 25},
+		"regular day with 24 hours":    {2026, int(time.January), 12},
+	}
+
+	for name, date := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end := getDstDay(t, date[0], time.Month(date[1]), date[2])
+			server := newApagaLuzServer(t, start, end)
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.ApagaLuz.URL = server.URL
+
+			provider, err := NewApagaLuzProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, start, end)
+
+			// Prices keep the order of the feed, so the repeated hour is not merged with the first one
+			for index, item := range prices {
+				if item.Price != float64(index) {
+					t.Errorf("expected price %d for the hour starting at %s, got %.0f", index, item.Start, item.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestGenericDstFallBackWithoutOffset(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.October, 25)
+
+	// Timestamps are expressed in local time without offset, so 02:00 comes twice
+	var items []interface{}
+	for instant := start; instant.Before(end); instant = instant.Add(time.Hour) {
+		items = append(items, map[string]interface{}{
+			"time":  instant.Format("2006-01-02 15:04"),
+			"price": float64(len(items)),
+		})
+	}
+
+	priceMapping := v1alpha1.PriceMappingSpec{
+		Items:           "prices",
+		Fields:          v1alpha1.PriceMappingFieldsSpec{Timestamp: "time", Price: "price"},
+		TimestampLayout: "2006-01-02 15:04",
+		Timezone:        ApagaLuzApiTimeLocation,
+	}
+
+	prices, err := mapJsonPrices(map[string]interface{}{"prices": items}, priceMapping, start.Location(), "", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertConsecutiveHours(t, prices, start, end)
+}
+
+func TestBestSchedulesDstDays(t *testing.T) {
+
+	tests := map[string][3]int{
+		"spring forward with 23 hours": {2026, int(time.March), 29},
+		"fall back with 25 hours":      {2026, int(time.October), 25},
+	}
+
+	for name, date := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end := getDstDay(t, date[0], time.Month(date[1]), date[2])
+			server := newApagaLuzServer(t, start, end)
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Provider = ProviderApagaLuz
+			ctx.Config.Spec.Price.ApagaLuz.URL = server.URL
+			ctx.Config.Spec.Device.ActiveDuration = "4h"
+
+			activeDuration, err := GetActiveDuration(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Prices grow along the day, so the first hours are the cheapest ones, crossing the DST change
+			schedules, _, err := GetBestSchedules(ctx, start, end, activeDuration, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(schedules) != 1 {
+				t.Fatalf("expected a single schedule, got %d: %v", len(schedules), schedules)
+			}
+
+			if !schedules[0].Start.Equal(start) || schedules[0].Stop.Sub(schedules[0].Start) != activeDuration {
+				t.Errorf("expected a schedule of %s from %s, got from %s to %s",
+					activeDuration, start, schedules[0].Start, schedules[0].Stop)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+// ATTENTION:
+// ENTSO-E Transparency Platform publishes the day-ahead prices for every European bidding zone.
+// An API token is required to use it, and it can be requested from the account settings of the platform
+// [ENTSO-E] Ref: https://transparency.entsoe.eu/content/static_content/Static%20content/web%20api/Guide.html
+
+package price
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	EntsoeAPIUrl = "https://web-api.tp.entsoe.eu/api"
+
+	// Day-ahead prices document type
+	EntsoeDayAheadDocumentType = "A44"
+
+	// A03 curves omit the points whose value is the same as the previous one
+	EntsoeVariableSizedBlockCurveType = "A03"
+
+	// Reason of the rejected requests when there is no data for the requested range.
+	// The code is shared with other reasons, so the text is checked too
+	EntsoeNoMatchingDataReasonCode = "999"
+	EntsoeNoMatchingDataReasonText = "No matching data"
+
+	//
+	entsoePeriodLayout   = "200601021504"
+	entsoeIntervalLayout = "2006-01-02T15:04Z07:00"
+
+	//
+	EntsoeTokenNotFoundErrorMessage        = "config.price.entsoe.token field is required to use ENTSO-E provider"
+	EntsoeZoneNotSupportedErrorMessage     = "zone '%s' is not supported by ENTSO-E provider"
+	EntsoeHttpRequestFailedErrorMessage    = "error performing http request to ENTSO-E: %s"
+	EntsoeHttpResponseDecodingErrorMessage = "error decoding ENTSO-E response: %s"
+	EntsoeAcknowledgementErrorMessage      = "ENTSO-E rejected the request: %s"
+	EntsoeResolutionNotSupportedMessage    = "resolution '%s' is not supported"
+)
+
+// EntsoeBiddingZoneSpec represents the EIC code of a bidding zone and the timezone its prices are referred to
+type EntsoeBiddingZoneSpec struct {
+	Code     string
+	Location string
+}
+
+// entsoeBiddingZones represents the relation between the friendly names on 'price.zone' and ENTSO-E bidding zones
+var entsoeBiddingZones = map[string]EntsoeBiddingZoneSpec{
+	"AT":       {Code: "10YAT-APG------L", Location: "Europe/Vienna"},
+	"BE":       {Code: "10YBE----------2", Location: "Europe/Brussels"},
+	"BG":       {Code: "10YCA-BULGARIA-R", Location: "Europe/Sofia"},
+	"CH":       {Code: "10YCH-SWISSGRIDZ", Location: "Europe/Zurich"},
+	"CZ":       {Code: "10YCZ-CEPS-----N", Location: "Europe/Prague"},
+	"DE-LU":    {Code: "10Y1001A1001A82H", Location: "Europe/Berlin"},
+	"DK1":      {Code: "10YDK-1--------W", Location: "Europe/Copenhagen"},
+	"DK2":      {Code: "10YDK-2--------M", Location: "Europe/Copenhagen"},
+	"EE":       {Code: "10Y1001A1001A39I", Location: "Europe/Tallinn"},
+	"ES":       {Code: "10YES-REE------0", Location: "Europe/Madrid"},
+	"FI":       {Code: "10YFI-1--------U", Location: "Europe/Helsinki"},
+	"FR":       {Code: "10YFR-RTE------C", Location: "Europe/Paris"},
+	"GR":       {Code: "10YGR-HTSO-----Y", Location: "Europe/Athens"},
+	"HR":       {Code: "10YHR-HEP------M", Location: "Europe/Zagreb"},
+	"HU":       {Code: "10YHU-MAVIR----U", Location: "Europe/Budapest"},
+	"IE-SEM":   {Code: "10Y1001A1001A59C", Location: "Europe/Dublin"},
+	"IT-NORTH": {Code: "10Y1001A1001A73I", Location: "Europe/Rome"},
+	"LT":       {Code: "10YLT-1001A0008Q", Location: "Europe/Vilnius"},
+	"LV":       {Code: "10YLV-1001A00074", Location: "Europe/Riga"},
+	"NL":       {Code: "10YNL----------L", Location: "Europe/Amsterdam"},
+	"NO1":      {Code: "10YNO-1--------2", Location: "Europe/Oslo"},
+	"NO2":      {Code: "10YNO-2--------T", Location: "Europe/Oslo"},
+	"NO3":      {Code: "10YNO-3--------J", Location: "Europe/Oslo"},
+	"NO4":      {Code: "10YNO-4--------9", Location: "Europe/Oslo"},
+	"NO5":      {Code: "10Y1001A1001A48H", Location: "Europe/Oslo"},
+	"PL":       {Code: "10YPL-AREA-----S", Location: "Europe/Warsaw"},
+	"PT":       {Code: "10YPT-REN------W", Location: "Europe/Lisbon"},
+	"RO":       {Code: "10YRO-TEL------P", Location: "Europe/Bucharest"},
+	"SE1":      {Code: "10Y1001A1001A44P", Location: "Europe/Stockholm"},
+	"SE2":      {Code: "10Y1001A1001A45N", Location: "Europe/Stockholm"},
+	"SE3":      {Code: "10Y1001A1001A46L", Location: "Europe/Stockholm"},
+	"SE4":      {Code: "10Y1001A1001A47J", Location: "Europe/Stockholm"},
+	"SI":       {Code: "10YSI-ELES-----O", Location: "Europe/Ljubljana"},
+	"SK":       {Code: "10YSK-SEPS-----K", Location: "Europe/Bratislava"},
+}
+
+// EntsoePublicationMarketDocumentSpec represents the document returned by ENTSO-E for day-ahead prices.
+// DISCLAIMER: NOT all the fields are covered. Only those that are needed to know the prices
+type EntsoePublicationMarketDocumentSpec struct {
+	XMLName    xml.Name               `xml:"Publication_MarketDocument"`
+	TimeSeries []EntsoeTimeSeriesSpec `xml:"TimeSeries"`
+}
+
+// EntsoeTimeSeriesSpec TODO
+type EntsoeTimeSeriesSpec struct {
+	Currency    string             `xml:"currency_Unit.name"`
+	MeasureUnit string             `xml:"price_Measure_Unit.name"`
+	CurveType   string             `xml:"curveType"`
+	Period      []EntsoePeriodSpec `xml:"Period"`
+}
+
+// EntsoePeriodSpec TODO
+type EntsoePeriodSpec struct {
+	TimeInterval struct {
+		Start string `xml:"start"`
+		End   string `xml:"end"`
+	} `xml:"timeInterval"`
+	Resolution string            `xml:"resolution"`
+	Point      []EntsoePointSpec `xml:"Point"`
+}
+
+// EntsoePointSpec TODO
+type EntsoePointSpec struct {
+	Position int     `xml:"position"`
+	Price    float64 `xml:"price.amount"`
+}
+
+// EntsoeAcknowledgementMarketDocumentSpec represents the document returned by ENTSO-E when a request is rejected
+type EntsoeAcknowledgementMarketDocumentSpec struct {
+	XMLName xml.Name `xml:"Acknowledgement_MarketDocument"`
+	Reason  []struct {
+		Code string `xml:"code"`
+		Text string `xml:"text"`
+	} `xml:"Reason"`
+}
+
+// EntsoeProvider represents a price provider that retrieves day-ahead prices from ENTSO-E Transparency Platform
+type EntsoeProvider struct {
+	url        string
+	httpClient *http.Client
+	token      string
+	zone       string
+	code       string
+	location   *time.Location
+}
+
+// NewEntsoeProvider return an ENTSO-E provider configured for the bidding zone defined on 'price.entsoe.zone',
+// or on 'price.zone' when the provider does not define its own
+func NewEntsoeProvider(ctx *v1alpha1.Context) (provider *EntsoeProvider, err error) {
+
+	entsoeConfig := ctx.Config.Spec.Price.Entsoe
+
+	if entsoeConfig.Token == "" {
+		return provider, errors.New(EntsoeTokenNotFoundErrorMessage)
+	}
+
+	zone := GetProviderZone(ctx, ProviderEntsoe)
+	biddingZone, biddingZoneFound := entsoeBiddingZones[strings.ToUpper(zone)]
+	if !biddingZoneFound {
+		return provider, errors.New(fmt.Sprintf(EntsoeZoneNotSupportedErrorMessage, zone))
+	}
+
+	provider = &EntsoeProvider{
+		httpClient: httpx.GetClient(ctx),
+		url:        EntsoeAPIUrl,
+		token:      entsoeConfig.Token,
+		zone:       zone,
+		code:       biddingZone.Code,
+	}
+
+	if entsoeConfig.URL != "" {
+		provider.url = entsoeConfig.URL
+	}
+
+	provider.location, err = time.LoadLocation(biddingZone.Location)
+	return provider, err
+}
+
+// Location return the timezone of the configured bidding zone
+func (p *EntsoeProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the nominal time covered by each price returned by the provider.
+// European day-ahead markets use 15 minutes MTU, although some zones still publish hourly prices
+func (p *EntsoeProvider) Resolution() time.Duration {
+	return 15 * time.Minute
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end)
+func (p *EntsoeProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	// Encode everything as URL. Periods are always expressed in UTC
+	params := url.Values{}
+	params.Add("securityToken", p.token)
+	params.Add("documentType", EntsoeDayAheadDocumentType)
+	params.Add("in_Domain", p.code)
+	params.Add("out_Domain", p.code)
+	params.Add("periodStart", start.UTC().Format(entsoePeriodLayout))
+	params.Add("periodEnd", end.UTC().Format(entsoePeriodLayout))
+
+	requestUrl, err := url.Parse(p.url)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EntsoeHttpRequestFailedErrorMessage, err))
+	}
+	requestUrl.RawQuery = params.Encode()
+
+	httpRequest, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EntsoeHttpRequestFailedErrorMessage, err))
+	}
+
+	// Rejected requests are answered with an error status, so their body is kept to know the reason
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	var statusError *httpx.StatusError
+	if errors.As(err, &statusError) {
+		body = statusError.Body
+	} else if err != nil {
+		return prices, errors.New(fmt.Sprintf(EntsoeHttpRequestFailedErrorMessage, err))
+	}
+
+	// Rejected requests are answered with a different document explaining the reason
+	acknowledgement := EntsoeAcknowledgementMarketDocumentSpec{}
+	if xml.Unmarshal(body, &acknowledgement) == nil {
+		var reasons []string
+		for _, reason := range acknowledgement.Reason {
+
+			// Prices not published yet are rejected as there is no matching data
+			if reason.Code == EntsoeNoMatchingDataReasonCode && strings.Contains(reason.Text, EntsoeNoMatchingDataReasonText) {
+				return prices, ErrPricesIncomplete
+			}
+			reasons = append(reasons, reason.Text)
+		}
+		return prices, errors.New(fmt.Sprintf(EntsoeAcknowledgementErrorMessage, strings.Join(reasons, ", ")))
+	}
+
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EntsoeHttpRequestFailedErrorMessage, err))
+	}
+
+	// Decode response's XML into a struct
+	response := EntsoePublicationMarketDocumentSpec{}
+	err = xml.Unmarshal(body, &response)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EntsoeHttpResponseDecodingErrorMessage, err))
+	}
+
+	knownInstants := map[time.Time]bool{}
+
+	for _, timeSeries := range response.TimeSeries {
+		for _, period := range timeSeries.Period {
+
+			periodInstants, periodPrices, resolution, err := parseEntsoePeriod(period, timeSeries.CurveType)
+			if err != nil {
+				return prices, errors.New(fmt.Sprintf(EntsoeHttpResponseDecodingErrorMessage, err))
+			}
+
+			for index, instant := range periodInstants {
+
+				// Some zones publish several time series for the same moments. Only the first one is kept
+				if knownInstants[instant] || instant.Before(start) || !instant.Before(end) {
+					continue
+				}
+				knownInstants[instant] = true
+
+				// ENTSO-E express the prices in currency/MWh
+				prices = append(prices, NewSlot(instant.In(p.location), resolution, periodPrices[index]/1000, p.zone))
+			}
+		}
+	}
+
+	SortByTime(prices)
+	return prices, nil
+}
+
+// parseEntsoePeriod return the moment and the price for each position inside the period, and their resolution.
+// Positions omitted by A03 curves are filled with the price of the previous position
+func parseEntsoePeriod(period EntsoePeriodSpec, curveType string) (instants []time.Time, prices []float64,
+	resolution time.Duration, err error) {
+
+	periodStart, err := time.Parse(entsoeIntervalLayout, period.TimeInterval.Start)
+	if err != nil {
+		return instants, prices, resolution, err
+	}
+
+	periodEnd, err := time.Parse(entsoeIntervalLayout, period.TimeInterval.End)
+	if err != nil {
+		return instants, prices, resolution, err
+	}
+
+	resolution, err = parseEntsoeResolution(period.Resolution)
+	if err != nil {
+		return instants, prices, resolution, err
+	}
+
+	pointsByPosition := map[int]float64{}
+	for _, point := range period.Point {
+		pointsByPosition[point.Position] = point.Price
+	}
+
+	positions := int(periodEnd.Sub(periodStart) / resolution)
+	lastPrice := 0.0
+
+	for position := 1; position <= positions; position++ {
+		price, pointFound := pointsByPosition[position]
+
+		if !pointFound {
+			if curveType != EntsoeVariableSizedBlockCurveType || position == 1 {
+				continue
+			}
+			price = lastPrice
+		}
+
+		lastPrice = price
+		instants = append(instants, periodStart.Add(time.Duration(position-1)*resolution))
+		prices = append(prices, price)
+	}
+
+	return instants, prices, resolution, nil
+}
+
+// parseEntsoeResolution return the duration for the ISO 8601 resolutions used by ENTSO-E. i.e: PT15M, PT60M
+func parseEntsoeResolution(resolution string) (time.Duration, error) {
+
+	switch resolution {
+	case "PT15M":
+		return 15 * time.Minute, nil
+	case "PT30M":
+		return 30 * time.Minute, nil
+	case "PT60M", "PT1H":
+		return time.Hour, nil
+	}
+
+	return 0, errors.New(fmt.Sprintf(EntsoeResolutionNotSupportedMessage, resolution))
+}
@@ -0,0 +1,128 @@
+package price
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	// Day-ahead prices of a whole day in Spain, with a single hourly point as the rest have the same price
+	entsoePublicationFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<Publication_MarketDocument xmlns="urn:iec62325.351:tc57wg16:451-3:publicationdocument:7:3">
+	<TimeSeries>
+		<currency_Unit.name>EUR</currency_Unit.name>
+		<price_Measure_Unit.name>MWH</price_Measure_Unit.name>
+		<curveType>A03</curveType>
+		<Period>
+			<timeInterval>
+				<start>2026-01-11T23:00Z</start>
+				<end>2026-01-12T23:00Z</end>
+			</timeInterval>
+			<resolution>PT60M</resolution>
+			<Point>
+				<position>1</position>
+				<price.amount>100</price.amount>
+			</Point>
+		</Period>
+	</TimeSeries>
+</Publication_MarketDocument>`
+
+	// Answer of the requests for prices not published yet
+	entsoeNoMatchingDataFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<Acknowledgement_MarketDocument xmlns="urn:iec62325.351:tc57wg16:451-1:acknowledgementdocument:7:0">
+	<Reason>
+		<code>999</code>
+		<text>No matching data found for Data item ENERGY_PRICES</text>
+	</Reason>
+</Acknowledgement_MarketDocument>`
+
+	// Answer of the requests with a wrong token
+	entsoeUnauthorizedFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<Acknowledgement_MarketDocument xmlns="urn:iec62325.351:tc57wg16:451-1:acknowledgementdocument:7:0">
+	<Reason>
+		<code>999</code>
+		<text>Unauthorized. Missing or invalid security token</text>
+	</Reason>
+</Acknowledgement_MarketDocument>`
+)
+
+func TestEntsoeGetPrices(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+
+	tests := map[string]struct {
+		statusCode int
+		body       string
+
+		// Error expected, or text contained by it when it's not a known one
+		expectedError     error
+		expectedErrorText string
+	}{
+		"published prices": {
+			statusCode: http.StatusOK,
+			body:       entsoePublicationFixture,
+		},
+		"prices not published yet": {
+			statusCode:    http.StatusBadRequest,
+			body:          entsoeNoMatchingDataFixture,
+			expectedError: ErrPricesIncomplete,
+		},
+		"prices not published yet with a successful status": {
+			statusCode:    http.StatusOK,
+			body:          entsoeNoMatchingDataFixture,
+			expectedError: ErrPricesIncomplete,
+		},
+		"rejected token": {
+			statusCode:        http.StatusUnauthorized,
+			body:              entsoeUnauthorizedFixture,
+			expectedErrorText: "invalid security token",
+		},
+		"server failing": {
+			statusCode:        http.StatusServiceUnavailable,
+			body:              "Service Unavailable",
+			expectedErrorText: "503",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Zone = "ES"
+			ctx.Config.Spec.Price.Entsoe.Token = "token"
+			ctx.Config.Spec.Price.Entsoe.URL = server.URL
+
+			provider, err := NewEntsoeProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+
+			switch {
+			case test.expectedError != nil:
+				if !errors.Is(err, test.expectedError) {
+					t.Fatalf("expected the error '%s', got '%v'", test.expectedError, err)
+				}
+			case test.expectedErrorText != "":
+				if err == nil || errors.Is(err, ErrPricesIncomplete) || !strings.Contains(err.Error(), test.expectedErrorText) {
+					t.Fatalf("expected an error containing '%s', got '%v'", test.expectedErrorText, err)
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertConsecutiveHours(t, prices, start, end)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// ATTENTION:
+// ESIOS is the official datasource for PVPC published by Red Eléctrica. An API token is required to use it,
+// and it can be requested by email to consultasios@ree.es
+// [ESIOS] Ref: https://api.esios.ree.es/
+
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	EsiosAPIUrl = "https://api.esios.ree.es"
+
+	// PVPC 2.0TD active energy term indicator
+	EsiosPVPCIndicator = 1001
+
+	//
+	EsiosAcceptHeader = "application/json; application/vnd.esios-api-v1+json"
+	esiosDateLayout   = "2006-01-02T15:04"
+
+	//
+	EsiosTokenNotFoundErrorMessage        = "config.price.esios.token field is required to use ESIOS provider"
+	EsiosZoneNotSupportedErrorMessage     = "zone '%s' is not supported by ESIOS provider"
+	EsiosHttpRequestFailedErrorMessage    = "error performing http request to ESIOS: %s"
+	EsiosHttpResponseDecodingErrorMessage = "error decoding ESIOS response: %s"
+)
+
+// esiosGeoIds represents the relation between the zones on 'price.zone' and ESIOS geographical identifiers
+var esiosGeoIds = map[string]int{
+	"mainland":        8741,
+	"canaryislands":   8742,
+	"balearicislands": 8743,
+	"ceuta":           8744,
+	"melilla":         8745,
+}
+
+// EsiosResponseSpec represents the fields returned by ESIOS indicators endpoint.
+// DISCLAIMER: NOT all the fields are covered. Only those that are needed to know the prices
+type EsiosResponseSpec struct {
+	Indicator struct {
+		Id     int    `json:"id"`
+		Name   string `json:"name"`
+		Values []struct {
+			Value       float64 `json:"value"`
+			Datetime    string  `json:"datetime"`
+			DatetimeUTC string  `json:"datetime_utc"`
+			GeoId       int     `json:"geo_id"`
+			GeoName     string  `json:"geo_name"`
+		} `json:"values"`
+	} `json:"indicator"`
+}
+
+// EsiosProvider represents a price provider that retrieves PVPC prices directly from ESIOS API
+type EsiosProvider struct {
+	url        string
+	httpClient *http.Client
+	token      string
+	zone       string
+	geoId      int
+	location   *time.Location
+}
+
+// NewEsiosProvider return an ESIOS provider configured for the zone defined on 'price.esios.zone',
+// or on 'price.zone' when the provider does not define its own
+func NewEsiosProvider(ctx *v1alpha1.Context) (provider *EsiosProvider, err error) {
+
+	esiosConfig := ctx.Config.Spec.Price.Esios
+
+	if esiosConfig.Token == "" {
+		return provider, errors.New(EsiosTokenNotFoundErrorMessage)
+	}
+
+	zone := GetProviderZone(ctx, ProviderEsios)
+	if zone == "" {
+		zone = "mainland"
+	}
+
+	geoId, geoIdFound := esiosGeoIds[zone]
+	if !geoIdFound {
+		return provider, errors.New(fmt.Sprintf(EsiosZoneNotSupportedErrorMessage, zone))
+	}
+
+	provider = &EsiosProvider{
+		httpClient: httpx.GetClient(ctx),
+		url:        EsiosAPIUrl,
+		token:      esiosConfig.Token,
+		zone:       zone,
+		geoId:      geoId,
+	}
+
+	if esiosConfig.URL != "" {
+		provider.url = esiosConfig.URL
+	}
+
+	apiTimeLocation := "Europe/Madrid"
+	if zone == "canaryislands" {
+		apiTimeLocation = "Atlantic/Canary"
+	}
+
+	provider.location, err = time.LoadLocation(apiTimeLocation)
+	return provider, err
+}
+
+// Location return the timezone used to express the prices of the configured zone
+func (p *EsiosProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the time covered by each price returned by ESIOS
+func (p *EsiosProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+// GetPrices return the prices for the hours starting in the range [start, end)
+func (p *EsiosProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	// Encode everything as URL. Values are truncated by hour, as PVPC is published hourly
+	params := url.Values{}
+	params.Add("start_date", start.In(p.location).Format(esiosDateLayout))
+	params.Add("end_date", end.Add(-time.Minute).In(p.location).Format(esiosDateLayout))
+	params.Add("geo_ids[]", strconv.Itoa(p.geoId))
+	params.Add("time_trunc", "hour")
+
+	requestUrl, err := url.Parse(fmt.Sprintf("%s/indicators/%d", p.url, EsiosPVPCIndicator))
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EsiosHttpRequestFailedErrorMessage, err))
+	}
+	requestUrl.RawQuery = params.Encode()
+
+	httpRequest, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EsiosHttpRequestFailedErrorMessage, err))
+	}
+
+	httpRequest.Header.Set("Accept", EsiosAcceptHeader)
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("x-api-key", p.token)
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EsiosHttpRequestFailedErrorMessage, err))
+	}
+
+	// Decode response's JSON into a struct
+	response := EsiosResponseSpec{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(EsiosHttpResponseDecodingErrorMessage, err))
+	}
+
+	for _, value := range response.Indicator.Values {
+
+		// Some geographical zones can be mixed in the response when the filter is ignored
+		if value.GeoId != p.geoId {
+			continue
+		}
+
+		valueTime, err := time.Parse(time.RFC3339, value.DatetimeUTC)
+		if err != nil {
+			return prices, errors.New(fmt.Sprintf(EsiosHttpResponseDecodingErrorMessage, err))
+		}
+
+		if valueTime.Before(start) || !valueTime.Before(end) {
+			continue
+		}
+
+		// ESIOS express the prices in €/MWh
+		prices = append(prices, NewSlot(valueTime.In(p.location), time.Hour, value.Value/1000, p.zone))
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,76 @@
+package price
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEsiosServer return a fake ESIOS server answering the PVPC indicator with the hours of the given day,
+// mixing the values of the mainland and the Canary Islands as the real one does without the geographical filter
+func newEsiosServer(t *testing.T, token string, day time.Time) *httptest.Server {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/indicators/%d", EsiosPVPCIndicator) || r.Header.Get("x-api-key") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var values []string
+		for instant := day; instant.Before(day.AddDate(0, 0, 1)); instant = instant.Add(time.Hour) {
+			for geoId, value := range map[int]float64{esiosGeoIds["mainland"]: 150, esiosGeoIds["canaryislands"]: 90} {
+				values = append(values, fmt.Sprintf(`{"value": %g, "datetime": "%s", "datetime_utc": "%s", "geo_id": %d}`,
+					value, instant.Format(time.RFC3339), instant.UTC().Format(time.RFC3339), geoId))
+			}
+		}
+
+		_, _ = fmt.Fprintf(w, `{"indicator": {"id": %d, "values": [%s]}}`, EsiosPVPCIndicator, strings.Join(values, ","))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestEsiosPrices(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+	server := newEsiosServer(t, "token", start)
+
+	ctx := newDstContext(start)
+	ctx.Config.Spec.Price.Esios.Token = "token"
+	ctx.Config.Spec.Price.Esios.URL = server.URL
+
+	provider, err := NewEsiosProvider(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the mainland values are kept, converted from €/MWh
+	prices, err := provider.GetPrices(start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertConsecutiveHours(t, prices, start, end)
+
+	for _, slot := range prices {
+		if slot.Price != 0.15 {
+			t.Fatalf("expected the price 0.15 at %s, got %g", slot.Start, slot.Price)
+		}
+	}
+
+	// Rejected requests are reported
+	ctx.Config.Spec.Price.Esios.Token = "rejected"
+
+	provider, err = NewEsiosProvider(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = provider.GetPrices(start, end); err == nil {
+		t.Errorf("expected an error for a rejected request")
+	}
+}
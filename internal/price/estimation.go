@@ -0,0 +1,172 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Possible values for 'device.baseline.type'
+	BaselineTypeAverage    = "average"
+	BaselineTypeFixedStart = "fixedStart"
+
+	//
+	CostEstimationFailedMessage = "cost of the plan can not be estimated: %s"
+
+	//
+	BaselineTypeNotSupportedErrorMessage = "config.device.baseline.type field must be one of: average, fixedStart"
+	BaselineStartParsingErrorMessage     = "config.device.baseline.start field must be a time with the format HH:MM: %s"
+)
+
+// CostEstimation represents the expected cost of a plan, and the cost of running the device the same time
+// without looking at the prices
+type CostEstimation struct {
+
+	// Energy consumed by the device, expressed in kWh
+	Energy float64 `json:"energy"`
+
+	// Costs expressed in the currency of the provider, using the final prices
+	Cost         float64 `json:"cost"`
+	BaselineCost float64 `json:"baselineCost"`
+	Savings      float64 `json:"savings"`
+}
+
+// CheckBaseline return an error when the baseline defined on 'device.baseline' is not valid.
+// It's checked on start, so the estimations never fail later
+func CheckBaseline(ctx *v1alpha1.Context) (err error) {
+
+	baselineConfig := ctx.Config.Spec.Device.Baseline
+
+	switch baselineConfig.Type {
+	case "", BaselineTypeAverage:
+	case BaselineTypeFixedStart:
+		_, err = time.Parse(windowTimeLayout, baselineConfig.Start)
+		if err != nil {
+			return errors.New(fmt.Sprintf(BaselineStartParsingErrorMessage, err))
+		}
+	default:
+		return errors.New(BaselineTypeNotSupportedErrorMessage)
+	}
+
+	return nil
+}
+
+// GetCostEstimation return the cost of the given schedules for the device defined on 'device.power', compared to
+// the baseline defined on 'device.baseline'. Given prices must be sorted by time and cover the schedules.
+// Planning window start is used to place fixed start baselines. Everything is zero when the power is not defined
+func GetCostEstimation(ctx *v1alpha1.Context, prices SlotList, schedules []Schedule, windowStart time.Time) (estimation CostEstimation, err error) {
+
+	baselineConfig := ctx.Config.Spec.Device.Baseline
+	power := ctx.Config.Spec.Device.Power / 1000
+
+	if power <= 0 {
+		return estimation, nil
+	}
+
+	err = CheckBaseline(ctx)
+	if err != nil {
+		return estimation, err
+	}
+
+	plannedDuration := time.Duration(0)
+	for _, schedule := range schedules {
+		plannedDuration += schedule.Stop.Sub(schedule.Start)
+	}
+
+	averagePrice := getAveragePrice(prices)
+
+	switch baselineConfig.Type {
+	case "", BaselineTypeAverage:
+		estimation.BaselineCost = power * plannedDuration.Hours() * averagePrice
+
+	case BaselineTypeFixedStart:
+		baselineStart, _ := time.Parse(windowTimeLayout, baselineConfig.Start)
+
+		// The device is started at the first occurrence of the time since the beginning of the window
+		windowStart = windowStart.In(time.Local)
+		baselineSchedule := Schedule{
+			Start: time.Date(windowStart.Year(), windowStart.Month(), windowStart.Day(),
+				baselineStart.Hour(), baselineStart.Minute(), 0, 0, time.Local),
+		}
+		if baselineSchedule.Start.Before(windowStart) {
+			baselineSchedule.Start = baselineSchedule.Start.AddDate(0, 0, 1)
+		}
+		baselineSchedule.Stop = baselineSchedule.Start.Add(plannedDuration)
+
+		// Time of the baseline running past the known prices is not free, so it's priced at the average one
+		uncoveredDuration := plannedDuration - getCoveredDuration(prices, baselineSchedule)
+		estimation.BaselineCost = GetScheduleCost(prices, []Schedule{baselineSchedule}, power) +
+			power*uncoveredDuration.Hours()*averagePrice
+	}
+
+	estimation.Energy = power * plannedDuration.Hours()
+	estimation.Cost = GetScheduleCost(prices, schedules, power)
+	estimation.Savings = estimation.BaselineCost - estimation.Cost
+
+	return estimation, nil
+}
+
+// getAveragePrice return the average final price of the given slots, weighted by the duration of each one
+func getAveragePrice(prices SlotList) (averagePrice float64) {
+
+	pricesDuration := time.Duration(0)
+	for _, item := range prices {
+		averagePrice += item.FinalPrice * item.Duration.Hours()
+		pricesDuration += item.Duration
+	}
+
+	if pricesDuration > 0 {
+		averagePrice /= pricesDuration.Hours()
+	}
+
+	return averagePrice
+}
+
+// getCoveredDuration return the time of the given schedule covered by the given slots
+func getCoveredDuration(prices SlotList, schedule Schedule) (covered time.Duration) {
+
+	for _, item := range prices {
+		overlapStart, overlapEnd := getOverlap(item, schedule)
+		if overlapEnd.After(overlapStart) {
+			covered += overlapEnd.Sub(overlapStart)
+		}
+	}
+
+	return covered
+}
+
+// getOverlap return the range of time shared by the given slot and schedule.
+// The end is not after the start when they do not overlap
+func getOverlap(item Slot, schedule Schedule) (overlapStart time.Time, overlapEnd time.Time) {
+
+	overlapStart = item.Start
+	if schedule.Start.After(overlapStart) {
+		overlapStart = schedule.Start
+	}
+
+	overlapEnd = item.End()
+	if schedule.Stop.Before(overlapEnd) {
+		overlapEnd = schedule.Stop
+	}
+
+	return overlapStart, overlapEnd
+}
+
+// GetScheduleCost return the cost of running a device with the given power, in kW, during the given schedules.
+// Slots partially covered by a schedule only count for the covered time. Time without known prices is not counted
+func GetScheduleCost(prices SlotList, schedules []Schedule, power float64) (cost float64) {
+
+	for _, schedule := range schedules {
+		for _, item := range prices {
+			overlapStart, overlapEnd := getOverlap(item, schedule)
+			if overlapEnd.After(overlapStart) {
+				cost += power * overlapEnd.Sub(overlapStart).Hours() * item.FinalPrice
+			}
+		}
+	}
+
+	return cost
+}
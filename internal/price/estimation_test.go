@@ -0,0 +1,84 @@
+package price
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCostEstimation(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+
+	// Prices are 0.10 during the whole day but the last two hours, so the average is 0.125
+	prices := getDayPrices(0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10,
+		0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.10, 0.40, 0.40)
+
+	tests := map[string]struct {
+		baselineType  string
+		baselineStart string
+		power         float64
+
+		expectedBaselineCost float64
+	}{
+		"average baseline": {
+			baselineType: BaselineTypeAverage, power: 1000,
+			expectedBaselineCost: 3 * 0.125,
+		},
+		"fixed start baseline inside the prices": {
+			baselineType: BaselineTypeFixedStart, baselineStart: "21:00", power: 1000,
+			expectedBaselineCost: 0.10 + 0.40 + 0.40,
+		},
+		"fixed start baseline running past the prices": {
+			baselineType: BaselineTypeFixedStart, baselineStart: "22:00", power: 1000,
+			expectedBaselineCost: 0.40 + 0.40 + 0.125,
+		},
+		"estimation skipped without power": {
+			baselineType: "unknown",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newFilePricesContext(t, start, prices)
+			ctx.Config.Spec.Device.Power = test.power
+			ctx.Config.Spec.Device.Baseline.Type = test.baselineType
+			ctx.Config.Spec.Device.Baseline.Start = test.baselineStart
+
+			schedules, estimation, err := GetBestSchedules(ctx, start, start.Add(24*time.Hour), 3*time.Hour, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(schedules) == 0 {
+				t.Fatalf("expected some schedules")
+			}
+
+			if math.Abs(estimation.BaselineCost-test.expectedBaselineCost) > 1e-9 {
+				t.Errorf("expected a baseline cost of %.3f, got %.3f", test.expectedBaselineCost, estimation.BaselineCost)
+			}
+		})
+	}
+}
+
+func TestCostEstimationFailure(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+
+	ctx := newFilePricesContext(t, start, getDayPrices(0.05, 0.06, 0.07))
+	ctx.Config.Spec.Device.Power = 1000
+	ctx.Config.Spec.Device.Baseline.Type = BaselineTypeFixedStart
+	ctx.Config.Spec.Device.Baseline.Start = "25:00"
+
+	if CheckBaseline(ctx) == nil {
+		t.Errorf("expected the baseline to be rejected")
+	}
+
+	// Estimations are only informative, so the schedules are kept
+	schedules, _, err := GetBestSchedules(ctx, start, start.Add(24*time.Hour), 3*time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertSchedules(t, schedules, start, [2]time.Duration{0, 3 * time.Hour})
+}
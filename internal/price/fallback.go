@@ -0,0 +1,99 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	FallbackProviderFailedMessage          = "price provider '%s' failed, trying the next one: %s"
+	FallbackProviderEmptyMessage           = "price provider '%s' returned no prices, trying the next one"
+	FallbackProviderIncompleteMessage      = "price provider '%s' does not cover the requested range, trying the next one"
+	FallbackAllProvidersFailedErrorMessage = "all the price providers failed: %s"
+)
+
+// FallbackPriceProvider represents a price provider that tries several providers in order,
+// returning the prices from the first one covering the whole requested range
+type FallbackPriceProvider struct {
+	ctx       *v1alpha1.Context
+	names     []string
+	providers []PriceProvider
+}
+
+// NewFallbackPriceProvider return a provider that tries the given providers in order.
+// Names are only used for logging purposes
+func NewFallbackPriceProvider(ctx *v1alpha1.Context, names []string, providers []PriceProvider) *FallbackPriceProvider {
+	return &FallbackPriceProvider{
+		ctx:       ctx,
+		names:     names,
+		providers: providers,
+	}
+}
+
+// Location return the timezone of the first provider. Prices from the others are converted to it
+func (p *FallbackPriceProvider) Location() *time.Location {
+	return p.providers[0].Location()
+}
+
+// Resolution return the time covered by each price returned by the first provider
+func (p *FallbackPriceProvider) Resolution() time.Duration {
+	return p.providers[0].Resolution()
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end) from the first provider covering it.
+// When none of them covers the whole range, the prices reaching further are returned, so they are detected
+// as incomplete and requested again later
+func (p *FallbackPriceProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	var providerErrors []string
+	var partialPrices SlotList
+	incomplete := false
+
+	for index, provider := range p.providers {
+
+		providerPrices, err := provider.GetPrices(start, end)
+		if err != nil {
+			p.ctx.Logger.Infof(FallbackProviderFailedMessage, p.names[index], err)
+			providerErrors = append(providerErrors, fmt.Sprintf("%s: %s", p.names[index], err))
+			incomplete = incomplete || errors.Is(err, ErrPricesIncomplete)
+			continue
+		}
+
+		if len(providerPrices) == 0 {
+			p.ctx.Logger.Infof(FallbackProviderEmptyMessage, p.names[index])
+			providerErrors = append(providerErrors, fmt.Sprintf("%s: no prices", p.names[index]))
+			continue
+		}
+
+		// Express the prices in the same timezone, no matter the provider they come from
+		prices = SlotList{}
+		for _, item := range providerPrices {
+			prices = append(prices, NewSlot(item.Start.In(p.Location()), item.Duration, item.Price, item.Zone))
+		}
+		SortByTime(prices)
+
+		if CoversRange(prices, start, end) {
+			return prices, nil
+		}
+
+		// Not published yet by this provider, but maybe by the next one
+		p.ctx.Logger.Infof(FallbackProviderIncompleteMessage, p.names[index])
+		if len(partialPrices) == 0 || prices[len(prices)-1].End().After(partialPrices[len(partialPrices)-1].End()) {
+			partialPrices = prices
+		}
+	}
+
+	if len(partialPrices) > 0 {
+		return partialPrices, nil
+	}
+
+	if incomplete {
+		return nil, ErrPricesIncomplete
+	}
+
+	return nil, errors.New(fmt.Sprintf(FallbackAllProvidersFailedErrorMessage, strings.Join(providerErrors, "; ")))
+}
@@ -0,0 +1,126 @@
+package price
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"go.uber.org/zap"
+)
+
+// fakePriceProvider represents a provider returning hourly prices for the first hours of the requested range
+type fakePriceProvider struct {
+	hours int
+	err   error
+}
+
+func (p *fakePriceProvider) GetPrices(start time.Time, end time.Time) (SlotList, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	var prices []float64
+	for len(prices) < p.hours {
+		prices = append(prices, float64(p.hours))
+	}
+
+	return getHourlySlots(start, prices...), nil
+}
+
+func (p *fakePriceProvider) Location() *time.Location {
+	return time.UTC
+}
+
+func (p *fakePriceProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+func TestFallbackPriceProvider(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	tests := map[string]struct {
+		providers []PriceProvider
+
+		// Hours returned, or the expected error
+		expectedHours int
+		expectedError error
+	}{
+		"first provider covering the range": {
+			providers:     []PriceProvider{&fakePriceProvider{hours: 24}, &fakePriceProvider{hours: 25}},
+			expectedHours: 24,
+		},
+		"partial prices are skipped for a provider covering the range": {
+			providers:     []PriceProvider{&fakePriceProvider{hours: 12}, &fakePriceProvider{hours: 24}},
+			expectedHours: 24,
+		},
+		"prices reaching further when none covers the range": {
+			providers:     []PriceProvider{&fakePriceProvider{hours: 12}, &fakePriceProvider{hours: 18}},
+			expectedHours: 18,
+		},
+		"failing providers are skipped": {
+			providers:     []PriceProvider{&fakePriceProvider{err: errors.New("down")}, &fakePriceProvider{hours: 24}},
+			expectedHours: 24,
+		},
+		"prices not published by any provider": {
+			providers: []PriceProvider{
+				&fakePriceProvider{err: errors.New("down")}, &fakePriceProvider{err: ErrPricesIncomplete},
+			},
+			expectedError: ErrPricesIncomplete,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := &v1alpha1.Context{Logger: zap.NewNop().Sugar()}
+			provider := NewFallbackPriceProvider(ctx, make([]string, len(test.providers)), test.providers)
+
+			prices, err := provider.GetPrices(start, end)
+			if test.expectedError != nil {
+				if !errors.Is(err, test.expectedError) {
+					t.Fatalf("expected the error '%s', got '%v'", test.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(prices) != test.expectedHours {
+				t.Errorf("expected %d hours, got %d", test.expectedHours, len(prices))
+			}
+		})
+	}
+}
+
+func TestNewPriceProviderZones(t *testing.T) {
+
+	ctx := &v1alpha1.Context{Config: &v1alpha1.ConfigSpec{}, Logger: zap.NewNop().Sugar()}
+	ctx.Config.Spec.Price.Providers = []string{ProviderNordPool, ProviderAwattar, ProviderEntsoe}
+	ctx.Config.Spec.Price.Zone = "mainland"
+	ctx.Config.Spec.Price.Awattar.Zone = "AT"
+
+	// Nord Pool does not know the zone and ENTSO-E has no token, so only aWATTar is kept
+	provider, err := NewPriceProvider(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	awattarProvider, ok := provider.(*AwattarProvider)
+	if !ok {
+		t.Fatalf("expected the aWATTar provider, got %T", provider)
+	}
+
+	if awattarProvider.country != "AT" {
+		t.Errorf("expected the zone 'AT', got '%s'", awattarProvider.country)
+	}
+
+	// Nothing is left when every provider fails
+	ctx.Config.Spec.Price.Awattar.Zone = ""
+	if _, err = NewPriceProvider(ctx); err == nil {
+		t.Errorf("expected an error when none of the providers can be built")
+	}
+}
@@ -0,0 +1,175 @@
+package price
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/mapping"
+)
+
+const (
+	// Possible values for 'price.file.format'
+	FileFormatCSV  = "csv"
+	FileFormatJSON = "json"
+
+	//
+	FilePathNotFoundErrorMessage       = "config.price.file.path field is required to use file provider"
+	FileFormatNotSupportedErrorMessage = "file format '%s' is not supported. Possible values: csv, json"
+	FileReadingErrorMessage            = "error reading prices file: %s"
+	FileColumnNotFoundErrorMessage     = "column '%s' not found on prices file"
+)
+
+// FileProvider represents a price provider that reads the prices from a CSV or JSON file on disk.
+// The file is read again on each request, so it can be edited or replaced at any moment
+type FileProvider struct {
+	path      string
+	format    string
+	delimiter rune
+	mapping   v1alpha1.PriceMappingSpec
+	zone      string
+	location  *time.Location
+}
+
+// NewFileProvider return a file provider configured as defined on 'price.file'.
+// When the format is not defined, it's guessed from the extension of the file
+func NewFileProvider(ctx *v1alpha1.Context) (provider *FileProvider, err error) {
+
+	fileConfig := ctx.Config.Spec.Price.File
+
+	if fileConfig.Path == "" {
+		return provider, errors.New(FilePathNotFoundErrorMessage)
+	}
+
+	provider = &FileProvider{
+		path:      fileConfig.Path,
+		format:    strings.ToLower(fileConfig.Format),
+		delimiter: ',',
+		mapping:   fileConfig.Mapping,
+		zone:      GetProviderZone(ctx, ProviderFile),
+	}
+
+	if provider.format == "" {
+		provider.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileConfig.Path)), ".")
+	}
+
+	if provider.format != FileFormatCSV && provider.format != FileFormatJSON {
+		return provider, errors.New(fmt.Sprintf(FileFormatNotSupportedErrorMessage, provider.format))
+	}
+
+	if fileConfig.Delimiter != "" {
+		provider.delimiter = []rune(fileConfig.Delimiter)[0]
+	}
+
+	provider.location, err = loadMappingLocation(fileConfig.Mapping)
+	return provider, err
+}
+
+// Location return the timezone defined on the mapping
+func (p *FileProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the nominal time covered by each price returned by the provider.
+// Each price lasts until the next one, so sub-hourly prices are kept as they are
+func (p *FileProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end)
+func (p *FileProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	fileBytes, err := os.ReadFile(p.path)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(FileReadingErrorMessage, err))
+	}
+
+	if p.format == FileFormatJSON {
+		var document interface{}
+		err = json.Unmarshal(fileBytes, &document)
+		if err != nil {
+			return prices, errors.New(fmt.Sprintf(FileReadingErrorMessage, err))
+		}
+
+		return mapJsonPrices(document, p.mapping, p.location, p.zone, start, end)
+	}
+
+	return p.getCsvPrices(fileBytes, start, end)
+}
+
+// getCsvPrices return the prices in the range [start, end) found on a CSV file.
+// The first row of the file must contain the names of the columns, used as fields on the mapping
+func (p *FileProvider) getCsvPrices(fileBytes []byte, start time.Time, end time.Time) (prices SlotList, err error) {
+
+	if p.mapping.Fields.Timestamp == "" || p.mapping.Fields.Price == "" {
+		return prices, errors.New(GenericFieldsNotFoundErrorMessage)
+	}
+
+	csvReader := csv.NewReader(strings.NewReader(string(fileBytes)))
+	csvReader.Comma = p.delimiter
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(FileReadingErrorMessage, err))
+	}
+
+	if len(records) == 0 {
+		return prices, nil
+	}
+
+	columns := map[string]int{}
+	for index, column := range records[0] {
+		columns[strings.TrimSpace(column)] = index
+	}
+
+	timestampColumn, timestampFound := columns[p.mapping.Fields.Timestamp]
+	if !timestampFound {
+		return prices, errors.New(fmt.Sprintf(FileColumnNotFoundErrorMessage, p.mapping.Fields.Timestamp))
+	}
+
+	priceColumn, priceFound := columns[p.mapping.Fields.Price]
+	if !priceFound {
+		return prices, errors.New(fmt.Sprintf(FileColumnNotFoundErrorMessage, p.mapping.Fields.Price))
+	}
+
+	unitColumn, unitFound := columns[p.mapping.Fields.Unit]
+
+	var instants []time.Time
+	var instantPrices []float64
+	var previousInstant time.Time
+
+	for _, record := range records[1:] {
+
+		unit := p.mapping.Unit
+		if unitFound {
+			unit = record[unitColumn]
+		}
+
+		instant, price, err := parseMappedItem(record[timestampColumn], record[priceColumn], unit,
+			p.mapping.TimestampLayout, p.location)
+		if err != nil {
+			return prices, err
+		}
+
+		// The repeated hour of DST changes comes twice with the same timestamp when there is no offset
+		instant = mapping.DisambiguateInstant(instant, previousInstant)
+		previousInstant = instant
+
+		if instant.Before(start) || !instant.Before(end) {
+			continue
+		}
+
+		instants = append(instants, instant)
+		instantPrices = append(instantPrices, price)
+	}
+
+	prices = newSlotsFromInstants(instants, instantPrices, p.location, p.zone, time.Hour)
+	return prices, nil
+}
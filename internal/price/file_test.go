@@ -0,0 +1,221 @@
+package price
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+// writePricesFile write the given content into a file with the given name inside a temporary directory
+func writePricesFile(t *testing.T, name string, content string) string {
+
+	filePath := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return filePath
+}
+
+func TestFilePrices(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+
+	var csvLines, jsonItems []string
+	for instant := start; instant.Before(end); instant = instant.Add(time.Hour) {
+		csvLines = append(csvLines, fmt.Sprintf("%s;%d,5;€/MWh", instant.Format("2006-01-02 15:04"), instant.Hour()*10))
+		jsonItems = append(jsonItems, fmt.Sprintf(`{"start": %d, "price": {"value": %g}}`,
+			instant.UnixMilli(), float64(instant.Hour())/100+0.0005))
+	}
+
+	csvPath := writePricesFile(t, "prices.csv", "start; price; unit\n"+strings.Join(csvLines, "\n"))
+	jsonPath := writePricesFile(t, "prices.txt", `{"prices": [`+strings.Join(jsonItems, ",")+`]}`)
+
+	tests := map[string]struct {
+		file         v1alpha1.FilePriceSpec
+		expectedZone string
+
+		// The file may not match the mapping
+		expectError bool
+	}{
+		"csv file with a unit column and a delimiter": {
+			file: v1alpha1.FilePriceSpec{
+				Path:      csvPath,
+				Delimiter: ";",
+				Mapping: v1alpha1.PriceMappingSpec{
+					Fields:          v1alpha1.PriceMappingFieldsSpec{Timestamp: "start", Price: "price", Unit: "unit"},
+					TimestampLayout: "2006-01-02 15:04",
+					Timezone:        ApagaLuzApiTimeLocation,
+				},
+			},
+			expectedZone: "ES",
+		},
+		"json file with an explicit format and its own zone": {
+			file: v1alpha1.FilePriceSpec{
+				Path:   jsonPath,
+				Format: FileFormatJSON,
+				Mapping: v1alpha1.PriceMappingSpec{
+					Items:           "prices",
+					Fields:          v1alpha1.PriceMappingFieldsSpec{Timestamp: "start", Price: "price.value"},
+					TimestampLayout: TimestampLayoutUnixMilli,
+					Unit:            "€/kWh",
+					Timezone:        ApagaLuzApiTimeLocation,
+				},
+				Zone: "PT",
+			},
+			expectedZone: "PT",
+		},
+		"column not found on the csv file": {
+			file: v1alpha1.FilePriceSpec{
+				Path:      csvPath,
+				Delimiter: ";",
+				Mapping: v1alpha1.PriceMappingSpec{
+					Fields: v1alpha1.PriceMappingFieldsSpec{Timestamp: "time", Price: "price"},
+				},
+			},
+			expectError: true,
+		},
+		"list of prices not found on the json file": {
+			file: v1alpha1.FilePriceSpec{
+				Path:   jsonPath,
+				Format: FileFormatJSON,
+				Mapping: v1alpha1.PriceMappingSpec{
+					Items:  "data.prices",
+					Fields: v1alpha1.PriceMappingFieldsSpec{Timestamp: "start", Price: "price.value"},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Zone = "ES"
+			ctx.Config.Spec.Price.File = test.file
+
+			provider, err := NewFileProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the prices %v", prices)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, start, end)
+
+			// Both files contain the same prices, expressed in different units
+			for index, item := range prices {
+				expectedPrice := float64(index)/100 + 0.0005
+				if diff := item.Price - expectedPrice; diff > 1e-9 || diff < -1e-9 || item.Zone != test.expectedZone {
+					t.Errorf("expected the price %g on zone %s for hour %d, got %g on zone %s",
+						expectedPrice, test.expectedZone, index, item.Price, item.Zone)
+				}
+			}
+		})
+	}
+}
+
+func TestFileFormat(t *testing.T) {
+
+	tests := map[string]struct {
+		path   string
+		format string
+
+		// The format may not be supported
+		expectError bool
+	}{
+		"guessed from the extension": {
+			path: "/etc/autoheater/prices.JSON",
+		},
+		"explicit format": {
+			path:   "/etc/autoheater/prices.txt",
+			format: "CSV",
+		},
+		"unknown extension": {
+			path:        "/etc/autoheater/prices.txt",
+			expectError: true,
+		},
+		"path not defined": {
+			format:      FileFormatCSV,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(time.Now())
+			ctx.Config.Spec.Price.File.Path = test.path
+			ctx.Config.Spec.Price.File.Format = test.format
+
+			_, err := NewFileProvider(ctx)
+			if (err != nil) != test.expectError {
+				t.Errorf("expected error %t, got '%v'", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestFileDstDays(t *testing.T) {
+
+	tests := map[string]struct {
+		month time.Month
+		day   int
+	}{
+		"spring forward": {
+			month: time.March,
+			day:   29,
+		},
+		"fall back": {
+			month: time.October,
+			day:   25,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end := getDstDay(t, 2026, test.month, test.day)
+
+			// Timestamps are written by their wall clock, so the repeated hour comes twice and the skipped one is missing
+			lines := []string{"time,price"}
+			for instant := start; instant.Before(end); instant = instant.Add(time.Hour) {
+				lines = append(lines, fmt.Sprintf("%s,0.1", instant.Format("2006-01-02 15:04")))
+			}
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.File.Path = writePricesFile(t, "prices.csv", strings.Join(lines, "\n"))
+			ctx.Config.Spec.Price.File.Mapping = v1alpha1.PriceMappingSpec{
+				Fields:          v1alpha1.PriceMappingFieldsSpec{Timestamp: "time", Price: "price"},
+				TimestampLayout: "2006-01-02 15:04",
+				Timezone:        ApagaLuzApiTimeLocation,
+			}
+
+			provider, err := NewFileProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, start, end)
+		})
+	}
+}
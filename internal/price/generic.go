@@ -0,0 +1,225 @@
+// ATTENTION:
+// Generic providers are intended for those tariffs that have no dedicated provider. The config declares where
+// the list of prices is, and which fields hold the timestamp, the price and its unit.
+// Prices are always converted to currency/kWh, as the rest of providers do
+
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+	"github.com/achetronic/autoheater/internal/mapping"
+)
+
+const (
+	// Special values for 'timestampLayout' field
+	TimestampLayoutUnix      = mapping.TimestampLayoutUnix
+	TimestampLayoutUnixMilli = mapping.TimestampLayoutUnixMilli
+
+	//
+	GenericUrlNotFoundErrorMessage       = "config.price.genericHttp.url field is required to use genericHttp provider"
+	GenericFieldsNotFoundErrorMessage    = "fields 'timestamp' and 'price' are required on the field mapping"
+	GenericItemsNotFoundErrorMessage     = "list of prices not found on path '%s'"
+	GenericFieldNotFoundErrorMessage     = "field '%s' not found on the item %d"
+	GenericTimestampParsingErrorMessage  = "impossible to parse the timestamp '%v': %s"
+	GenericPriceParsingErrorMessage      = "impossible to parse the price '%v'"
+	GenericUnitNotSupportedErrorMessage  = "price unit '%s' is not supported"
+	GenericHttpRequestFailedErrorMessage = "error performing http request to generic provider: %s"
+	GenericResponseDecodingErrorMessage  = "error decoding prices from generic provider: %s"
+)
+
+// GenericHttpProvider represents a price provider that retrieves the prices from any HTTP endpoint returning JSON
+type GenericHttpProvider struct {
+	url        string
+	httpClient *http.Client
+	headers    map[string]string
+	mapping    v1alpha1.PriceMappingSpec
+	zone       string
+	location   *time.Location
+}
+
+// NewGenericHttpProvider return a generic HTTP provider configured as defined on 'price.genericHttp'
+func NewGenericHttpProvider(ctx *v1alpha1.Context) (provider *GenericHttpProvider, err error) {
+
+	genericConfig := ctx.Config.Spec.Price.GenericHttp
+
+	if genericConfig.URL == "" {
+		return provider, errors.New(GenericUrlNotFoundErrorMessage)
+	}
+
+	provider = &GenericHttpProvider{
+		httpClient: httpx.GetClient(ctx),
+		url:        genericConfig.URL,
+		headers:    genericConfig.Headers,
+		mapping:    genericConfig.Mapping,
+		zone:       GetProviderZone(ctx, ProviderGeneric),
+	}
+
+	provider.location, err = loadMappingLocation(genericConfig.Mapping)
+	return provider, err
+}
+
+// Location return the timezone defined on the mapping
+func (p *GenericHttpProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the nominal time covered by each price returned by the provider.
+// Each price lasts until the next one, so sub-hourly prices are kept as they are
+func (p *GenericHttpProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end)
+func (p *GenericHttpProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	httpRequest, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(GenericHttpRequestFailedErrorMessage, err))
+	}
+
+	for headerName, headerValue := range p.headers {
+		httpRequest.Header.Set(headerName, headerValue)
+	}
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(GenericHttpRequestFailedErrorMessage, err))
+	}
+
+	var document interface{}
+	err = json.Unmarshal(body, &document)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(GenericResponseDecodingErrorMessage, err))
+	}
+
+	return mapJsonPrices(document, p.mapping, p.location, p.zone, start, end)
+}
+
+// loadMappingLocation return the timezone defined on the mapping. Timezone of the system is used by default
+func loadMappingLocation(priceMapping v1alpha1.PriceMappingSpec) (location *time.Location, err error) {
+	return mapping.LoadLocation(priceMapping.Timezone)
+}
+
+// mapJsonPrices return the prices in the range [start, end) found on a decoded JSON document,
+// following the path and the fields defined on the mapping
+func mapJsonPrices(document interface{}, priceMapping v1alpha1.PriceMappingSpec, location *time.Location, zone string,
+	start time.Time, end time.Time) (prices SlotList, err error) {
+
+	if priceMapping.Fields.Timestamp == "" || priceMapping.Fields.Price == "" {
+		return prices, errors.New(GenericFieldsNotFoundErrorMessage)
+	}
+
+	itemsValue, itemsFound := mapping.LookupPath(document, priceMapping.Items)
+	items, itemsAreList := itemsValue.([]interface{})
+	if !itemsFound || !itemsAreList {
+		return prices, errors.New(fmt.Sprintf(GenericItemsNotFoundErrorMessage, priceMapping.Items))
+	}
+
+	var instants []time.Time
+	var instantPrices []float64
+	var previousInstant time.Time
+
+	for index, item := range items {
+
+		timestampValue, timestampFound := mapping.LookupPath(item, priceMapping.Fields.Timestamp)
+		if !timestampFound {
+			return prices, errors.New(fmt.Sprintf(GenericFieldNotFoundErrorMessage, priceMapping.Fields.Timestamp, index))
+		}
+
+		priceValue, priceFound := mapping.LookupPath(item, priceMapping.Fields.Price)
+		if !priceFound {
+			return prices, errors.New(fmt.Sprintf(GenericFieldNotFoundErrorMessage, priceMapping.Fields.Price, index))
+		}
+
+		unit := priceMapping.Unit
+		if priceMapping.Fields.Unit != "" {
+			unitValue, unitFound := mapping.LookupPath(item, priceMapping.Fields.Unit)
+			if unitFound {
+				unit = fmt.Sprint(unitValue)
+			}
+		}
+
+		instant, price, err := parseMappedItem(timestampValue, priceValue, unit, priceMapping.TimestampLayout, location)
+		if err != nil {
+			return prices, err
+		}
+
+		// The repeated hour of DST changes comes twice with the same timestamp when there is no offset
+		instant = mapping.DisambiguateInstant(instant, previousInstant)
+		previousInstant = instant
+
+		if instant.Before(start) || !instant.Before(end) {
+			continue
+		}
+
+		instants = append(instants, instant)
+		instantPrices = append(instantPrices, price)
+	}
+
+	prices = newSlotsFromInstants(instants, instantPrices, location, zone, time.Hour)
+	return prices, nil
+}
+
+// parseMappedItem return the moment and the price, converted to currency/kWh, from the raw values of an item
+func parseMappedItem(timestampValue interface{}, priceValue interface{}, unit string, timestampLayout string,
+	location *time.Location) (instant time.Time, price float64, err error) {
+
+	instant, err = mapping.ParseTimestamp(timestampValue, timestampLayout, location)
+	if err != nil {
+		return instant, price, errors.New(fmt.Sprintf(GenericTimestampParsingErrorMessage, timestampValue, err))
+	}
+
+	price, err = mapping.ParseNumber(priceValue)
+	if err != nil {
+		return instant, price, errors.New(fmt.Sprintf(GenericPriceParsingErrorMessage, priceValue))
+	}
+
+	unitFactor, err := getUnitFactor(unit)
+	if err != nil {
+		return instant, price, err
+	}
+
+	return instant, price * unitFactor, nil
+}
+
+// getUnitFactor return the factor to convert prices expressed in the given unit into currency/kWh.
+// i.e: €/MWh, EUR/kWh, c€/kWh, ct/kWh, p/kWh. Prices without unit are considered to be expressed in currency/kWh
+func getUnitFactor(unit string) (factor float64, err error) {
+
+	unit = strings.ToLower(strings.ReplaceAll(unit, " ", ""))
+	if unit == "" {
+		return 1, nil
+	}
+
+	currency, energy, energyFound := strings.Cut(unit, "/")
+	if !energyFound {
+		return factor, errors.New(fmt.Sprintf(GenericUnitNotSupportedErrorMessage, unit))
+	}
+
+	switch energy {
+	case "kwh":
+		factor = 1
+	case "mwh":
+		factor = 1.0 / 1000
+	case "wh":
+		factor = 1000
+	default:
+		return factor, errors.New(fmt.Sprintf(GenericUnitNotSupportedErrorMessage, unit))
+	}
+
+	// Subunits of the currency: cents, pence, etc.
+	switch currency {
+	case "c", "ct", "c€", "cent", "cents", "p", "pence", "öre", "øre", "ore":
+		factor = factor / 100
+	}
+
+	return factor, nil
+}
@@ -0,0 +1,179 @@
+package price
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+func TestGenericHttpPrices(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+
+	// Half of the day is expressed in €/MWh, and the other half in c€/kWh
+	var items []string
+	for instant := start; instant.Before(end); instant = instant.Add(time.Hour) {
+		item := fmt.Sprintf(`{"startsAt": "%s", "value": %d, "unit": "€/MWh"}`, instant.Format(time.RFC3339), instant.Hour()*10)
+		if instant.Hour() >= 12 {
+			item = fmt.Sprintf(`{"startsAt": "%s", "value": "%d", "unit": "c€/kWh"}`, instant.Format(time.RFC3339), instant.Hour())
+		}
+		items = append(items, item)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data": {"prices": [` + strings.Join(items, ",") + `]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		headers map[string]string
+		mapping v1alpha1.PriceMappingSpec
+
+		// The request or the mapping may fail
+		expectError bool
+	}{
+		"prices with their own unit": {
+			headers: map[string]string{"Authorization": "Bearer token"},
+			mapping: v1alpha1.PriceMappingSpec{
+				Items:  "$.data.prices",
+				Fields: v1alpha1.PriceMappingFieldsSpec{Timestamp: "startsAt", Price: "value", Unit: "unit"},
+				Unit:   "€/kWh",
+			},
+		},
+		"unauthorized request": {
+			mapping: v1alpha1.PriceMappingSpec{
+				Items:  "data.prices",
+				Fields: v1alpha1.PriceMappingFieldsSpec{Timestamp: "startsAt", Price: "value"},
+			},
+			expectError: true,
+		},
+		"fields not defined": {
+			headers: map[string]string{"Authorization": "Bearer token"},
+			mapping: v1alpha1.PriceMappingSpec{
+				Items: "data.prices",
+			},
+			expectError: true,
+		},
+		"field not found on the items": {
+			headers: map[string]string{"Authorization": "Bearer token"},
+			mapping: v1alpha1.PriceMappingSpec{
+				Items:  "data.prices",
+				Fields: v1alpha1.PriceMappingFieldsSpec{Timestamp: "startsAt", Price: "amount"},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.GenericHttp.URL = server.URL
+			ctx.Config.Spec.Price.GenericHttp.Headers = test.headers
+			ctx.Config.Spec.Price.GenericHttp.Mapping = test.mapping
+
+			provider, err := NewGenericHttpProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the prices %v", prices)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, start, end)
+
+			for index, item := range prices {
+				expectedPrice := float64(index) / 100
+				if diff := item.Price - expectedPrice; diff > 1e-9 || diff < -1e-9 {
+					t.Errorf("expected the price %g for hour %d, got %g", expectedPrice, index, item.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUnitFactor(t *testing.T) {
+
+	tests := map[string]struct {
+		unit     string
+		expected float64
+
+		// Some units are not supported
+		expectError bool
+	}{
+		"no unit": {
+			unit:     "",
+			expected: 1,
+		},
+		"currency per kWh": {
+			unit:     "EUR/kWh",
+			expected: 1,
+		},
+		"currency per MWh with spaces": {
+			unit:     "€ / MWh",
+			expected: 0.001,
+		},
+		"currency per Wh": {
+			unit:     "€/Wh",
+			expected: 1000,
+		},
+		"cents per kWh": {
+			unit:     "c€/kWh",
+			expected: 0.01,
+		},
+		"pence per kWh": {
+			unit:     "p/kWh",
+			expected: 0.01,
+		},
+		"öre per MWh": {
+			unit:     "öre/MWh",
+			expected: 0.00001,
+		},
+		"unit without energy": {
+			unit:        "€",
+			expectError: true,
+		},
+		"unknown energy unit": {
+			unit:        "€/GJ",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			factor, err := getUnitFactor(test.unit)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the factor %g", factor)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := factor - test.expected; diff > 1e-12 || diff < -1e-12 {
+				t.Errorf("expected the factor %g, got %g", test.expected, factor)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// ATTENTION:
+// Nord Pool publishes the day-ahead prices for the nordic, baltic and some central european delivery areas.
+// Delivery dates are always expressed in CET, no matter the area requested
+// [Nord Pool] Ref: https://data.nordpoolgroup.com/auction/day-ahead/prices
+
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	NordPoolAPIUrl = "https://dataportal-api.nordpoolgroup.com/api/DayAheadPrices"
+
+	//
+	NordPoolDefaultCurrency   = "EUR"
+	NordPoolDeliveryLocation  = "Europe/Oslo"
+	nordPoolDeliveryDayLayout = "2006-01-02"
+
+	//
+	NordPoolAreaNotSupportedErrorMessage     = "zone '%s' is not supported by Nord Pool provider"
+	NordPoolHttpRequestFailedErrorMessage    = "error performing http request to Nord Pool: %s"
+	NordPoolHttpResponseDecodingErrorMessage = "error decoding Nord Pool response: %s"
+)
+
+// nordPoolDeliveryAreas represents the relation between Nord Pool delivery areas and their timezones
+var nordPoolDeliveryAreas = map[string]string{
+	"AT":  "Europe/Vienna",
+	"BE":  "Europe/Brussels",
+	"DK1": "Europe/Copenhagen",
+	"DK2": "Europe/Copenhagen",
+	"EE":  "Europe/Tallinn",
+	"FI":  "Europe/Helsinki",
+	"FR":  "Europe/Paris",
+	"GER": "Europe/Berlin",
+	"LT":  "Europe/Vilnius",
+	"LV":  "Europe/Riga",
+	"NL":  "Europe/Amsterdam",
+	"NO1": "Europe/Oslo",
+	"NO2": "Europe/Oslo",
+	"NO3": "Europe/Oslo",
+	"NO4": "Europe/Oslo",
+	"NO5": "Europe/Oslo",
+	"PL":  "Europe/Warsaw",
+	"SE1": "Europe/Stockholm",
+	"SE2": "Europe/Stockholm",
+	"SE3": "Europe/Stockholm",
+	"SE4": "Europe/Stockholm",
+	"SYS": "Europe/Oslo",
+}
+
+// NordPoolResponseSpec represents the fields returned by Nord Pool day-ahead prices endpoint.
+// DISCLAIMER: NOT all the fields are covered. Only those that are needed to know the prices
+type NordPoolResponseSpec struct {
+	DeliveryDateCET  string `json:"deliveryDateCET"`
+	Currency         string `json:"currency"`
+	MultiAreaEntries []struct {
+		DeliveryStart string             `json:"deliveryStart"`
+		DeliveryEnd   string             `json:"deliveryEnd"`
+		EntryPerArea  map[string]float64 `json:"entryPerArea"`
+	} `json:"multiAreaEntries"`
+}
+
+// NordPoolProvider represents a price provider that retrieves day-ahead prices from Nord Pool
+type NordPoolProvider struct {
+	url              string
+	httpClient       *http.Client
+	area             string
+	currency         string
+	location         *time.Location
+	deliveryLocation *time.Location
+}
+
+// NewNordPoolProvider return a Nord Pool provider configured for the delivery area defined on 'price.nordpool.zone',
+// or on 'price.zone' when the provider does not define its own
+func NewNordPoolProvider(ctx *v1alpha1.Context) (provider *NordPoolProvider, err error) {
+
+	nordPoolConfig := ctx.Config.Spec.Price.NordPool
+
+	zone := GetProviderZone(ctx, ProviderNordPool)
+	area := strings.ToUpper(zone)
+	areaLocation, areaFound := nordPoolDeliveryAreas[area]
+	if !areaFound {
+		return provider, errors.New(fmt.Sprintf(NordPoolAreaNotSupportedErrorMessage, zone))
+	}
+
+	provider = &NordPoolProvider{
+		httpClient: httpx.GetClient(ctx),
+		url:        NordPoolAPIUrl,
+		area:       area,
+		currency:   NordPoolDefaultCurrency,
+	}
+
+	if nordPoolConfig.URL != "" {
+		provider.url = nordPoolConfig.URL
+	}
+
+	if nordPoolConfig.Currency != "" {
+		provider.currency = strings.ToUpper(nordPoolConfig.Currency)
+	}
+
+	provider.location, err = time.LoadLocation(areaLocation)
+	if err != nil {
+		return provider, err
+	}
+
+	provider.deliveryLocation, err = time.LoadLocation(NordPoolDeliveryLocation)
+	return provider, err
+}
+
+// Location return the timezone of the configured delivery area
+func (p *NordPoolProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the nominal time covered by each price returned by the provider.
+// Nord Pool publishes the day-ahead prices using 15 minutes MTU
+func (p *NordPoolProvider) Resolution() time.Duration {
+	return 15 * time.Minute
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end).
+// Nord Pool is requested once per delivery day covered by the range
+func (p *NordPoolProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	deliveryStart := start.In(p.deliveryLocation)
+	deliveryDay := time.Date(deliveryStart.Year(), deliveryStart.Month(), deliveryStart.Day(), 0, 0, 0, 0, p.deliveryLocation)
+
+	for ; deliveryDay.Before(end); deliveryDay = deliveryDay.AddDate(0, 0, 1) {
+
+		response, err := p.getDeliveryDay(deliveryDay)
+		if err != nil {
+			return prices, err
+		}
+
+		// Days without entries are not published yet, so the following ones are not either
+		if len(response.MultiAreaEntries) == 0 {
+			SortByTime(prices)
+			return prices, ErrPricesIncomplete
+		}
+
+		for _, entry := range response.MultiAreaEntries {
+			entryTime, err := time.Parse(time.RFC3339, entry.DeliveryStart)
+			if err != nil {
+				return prices, errors.New(fmt.Sprintf(NordPoolHttpResponseDecodingErrorMessage, err))
+			}
+
+			entryEndTime, err := time.Parse(time.RFC3339, entry.DeliveryEnd)
+			if err != nil {
+				return prices, errors.New(fmt.Sprintf(NordPoolHttpResponseDecodingErrorMessage, err))
+			}
+
+			entryPrice, entryFound := entry.EntryPerArea[p.area]
+			if !entryFound || entryTime.Before(start) || !entryTime.Before(end) {
+				continue
+			}
+
+			// Nord Pool express the prices in currency/MWh
+			prices = append(prices, NewSlot(entryTime.In(p.location), entryEndTime.Sub(entryTime), entryPrice/1000, p.area))
+		}
+	}
+
+	SortByTime(prices)
+	return prices, nil
+}
+
+// getDeliveryDay return the response from Nord Pool for the given delivery day.
+// The response has no entries when the day is not published yet
+func (p *NordPoolProvider) getDeliveryDay(deliveryDay time.Time) (response NordPoolResponseSpec, err error) {
+
+	// Encode everything as URL
+	params := url.Values{}
+	params.Add("date", deliveryDay.Format(nordPoolDeliveryDayLayout))
+	params.Add("market", "DayAhead")
+	params.Add("deliveryArea", p.area)
+	params.Add("currency", p.currency)
+
+	requestUrl, err := url.Parse(p.url)
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(NordPoolHttpRequestFailedErrorMessage, err))
+	}
+	requestUrl.RawQuery = params.Encode()
+
+	httpRequest, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(NordPoolHttpRequestFailedErrorMessage, err))
+	}
+
+	// Nord Pool answers with no content when the prices are not published yet, so the day is empty
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if httpx.IsStatus(err, http.StatusNoContent) {
+		return response, nil
+	}
+
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(NordPoolHttpRequestFailedErrorMessage, err))
+	}
+
+	// Decode response's JSON into a struct
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(NordPoolHttpResponseDecodingErrorMessage, err))
+	}
+
+	return response, nil
+}
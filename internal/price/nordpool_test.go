@@ -0,0 +1,70 @@
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newNordPoolServer return a fake Nord Pool server publishing hourly entries for the given delivery days,
+// and answering with no content for the rest, as the real one does before publishing them
+func newNordPoolServer(t *testing.T, area string, publishedDays ...time.Time) *httptest.Server {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, day := range publishedDays {
+			if r.URL.Query().Get("date") != day.Format(nordPoolDeliveryDayLayout) {
+				continue
+			}
+
+			response := NordPoolResponseSpec{DeliveryDateCET: day.Format(nordPoolDeliveryDayLayout)}
+			for instant := day; instant.Before(day.AddDate(0, 0, 1)); instant = instant.Add(time.Hour) {
+				response.MultiAreaEntries = append(response.MultiAreaEntries, struct {
+					DeliveryStart string             `json:"deliveryStart"`
+					DeliveryEnd   string             `json:"deliveryEnd"`
+					EntryPerArea  map[string]float64 `json:"entryPerArea"`
+				}{
+					DeliveryStart: instant.UTC().Format(time.RFC3339),
+					DeliveryEnd:   instant.Add(time.Hour).UTC().Format(time.RFC3339),
+					EntryPerArea:  map[string]float64{area: 100},
+				})
+			}
+
+			_ = json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNordPoolUnpublishedDays(t *testing.T) {
+
+	// Delivery days are expressed in CET, as the Spanish days in winter
+	start, end := getDstDay(t, 2026, time.January, 12)
+	end = end.AddDate(0, 0, 1)
+
+	server := newNordPoolServer(t, "FR", start)
+
+	ctx := newDstContext(start)
+	ctx.Config.Spec.Price.Zone = "FR"
+	ctx.Config.Spec.Price.NordPool.URL = server.URL
+
+	provider, err := NewNordPoolProvider(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first day is published, but the second one is answered with no content
+	prices, err := provider.GetPrices(start, end)
+	if !errors.Is(err, ErrPricesIncomplete) {
+		t.Fatalf("expected the error '%s', got '%v'", ErrPricesIncomplete, err)
+	}
+
+	assertConsecutiveHours(t, prices, start, start.AddDate(0, 0, 1))
+}
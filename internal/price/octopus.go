@@ -0,0 +1,184 @@
+// ATTENTION:
+// Octopus Energy publishes the unit rates of its Agile tariffs for each half-hour slot of the day,
+// for each one of the UK regions. No token is required to read the public tariffs
+// [Octopus] Ref: https://developer.octopus.energy/docs/api/
+
+package price
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	OctopusAPIUrl = "https://api.octopus.energy/v1"
+
+	//
+	OctopusDefaultProductCode = "AGILE-24-10-01"
+	OctopusApiTimeLocation    = "Europe/London"
+
+	// Possible values for 'price.octopus.vat'
+	OctopusVatInclusive = "inclusive"
+	OctopusVatExclusive = "exclusive"
+
+	//
+	OctopusTariffNotFoundErrorMessage       = "config.price.octopus.tariffCode or config.price.zone fields are required to use Octopus provider"
+	OctopusVatNotSupportedErrorMessage      = "config.price.octopus.vat field must be one of: inclusive, exclusive"
+	OctopusHttpRequestFailedErrorMessage    = "error performing http request to Octopus: %s"
+	OctopusHttpResponseDecodingErrorMessage = "error decoding Octopus response: %s"
+)
+
+// OctopusResponseSpec represents the fields returned by Octopus standard unit rates endpoint
+type OctopusResponseSpec struct {
+	Count   int    `json:"count"`
+	Next    string `json:"next"`
+	Results []struct {
+		ValueExcVat float64 `json:"value_exc_vat"`
+		ValueIncVat float64 `json:"value_inc_vat"`
+		ValidFrom   string  `json:"valid_from"`
+		ValidTo     string  `json:"valid_to"`
+	} `json:"results"`
+}
+
+// OctopusProvider represents a price provider that retrieves the unit rates of Octopus Agile tariffs
+type OctopusProvider struct {
+	url          string
+	httpClient   *http.Client
+	productCode  string
+	tariffCode   string
+	vatInclusive bool
+	location     *time.Location
+}
+
+// NewOctopusProvider return an Octopus provider configured for the tariff defined on 'price.octopus'.
+// When the tariff code is not defined, it's crafted from the product code and the region defined on 'price.zone'
+func NewOctopusProvider(ctx *v1alpha1.Context) (provider *OctopusProvider, err error) {
+
+	octopusConfig := ctx.Config.Spec.Price.Octopus
+
+	provider = &OctopusProvider{
+		httpClient:   httpx.GetClient(ctx),
+		url:          OctopusAPIUrl,
+		productCode:  OctopusDefaultProductCode,
+		tariffCode:   octopusConfig.TariffCode,
+		vatInclusive: true,
+	}
+
+	if octopusConfig.URL != "" {
+		provider.url = octopusConfig.URL
+	}
+
+	if octopusConfig.ProductCode != "" {
+		provider.productCode = octopusConfig.ProductCode
+	}
+
+	switch octopusConfig.Vat {
+	case "", OctopusVatInclusive:
+	case OctopusVatExclusive:
+		provider.vatInclusive = false
+	default:
+		return provider, errors.New(OctopusVatNotSupportedErrorMessage)
+	}
+
+	// Tariff codes for single rate electricity follow the pattern: E-1R-<product>-<region>
+	if provider.tariffCode == "" {
+		zone := GetProviderZone(ctx, ProviderOctopus)
+		if zone == "" {
+			return provider, errors.New(OctopusTariffNotFoundErrorMessage)
+		}
+		provider.tariffCode = fmt.Sprintf("E-1R-%s-%s", provider.productCode, strings.ToUpper(zone))
+	}
+
+	provider.location, err = time.LoadLocation(OctopusApiTimeLocation)
+	return provider, err
+}
+
+// Location return the timezone used by Octopus
+func (p *OctopusProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the time covered by each price returned by the provider.
+// Agile tariffs change their rates each half-hour
+func (p *OctopusProvider) Resolution() time.Duration {
+	return 30 * time.Minute
+}
+
+// GetPrices return the prices for the half-hours starting in the range [start, end).
+// Results are paginated by Octopus, so all the pages are requested
+func (p *OctopusProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	// Encode everything as URL
+	params := url.Values{}
+	params.Add("period_from", start.UTC().Format(time.RFC3339))
+	params.Add("period_to", end.UTC().Format(time.RFC3339))
+
+	requestUrl, err := url.Parse(fmt.Sprintf("%s/products/%s/electricity-tariffs/%s/standard-unit-rates/",
+		p.url, p.productCode, p.tariffCode))
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(OctopusHttpRequestFailedErrorMessage, err))
+	}
+	requestUrl.RawQuery = params.Encode()
+
+	for nextUrl := requestUrl.String(); nextUrl != ""; {
+
+		httpRequest, err := http.NewRequest(http.MethodGet, nextUrl, nil)
+		if err != nil {
+			return prices, errors.New(fmt.Sprintf(OctopusHttpRequestFailedErrorMessage, err))
+		}
+
+		body, err := httpx.Do(p.httpClient, httpRequest)
+		if err != nil {
+			return prices, errors.New(fmt.Sprintf(OctopusHttpRequestFailedErrorMessage, err))
+		}
+
+		// Decode response's JSON into a struct
+		response := OctopusResponseSpec{}
+		err = json.Unmarshal(body, &response)
+		if err != nil {
+			return prices, errors.New(fmt.Sprintf(OctopusHttpResponseDecodingErrorMessage, err))
+		}
+
+		for _, result := range response.Results {
+			resultTime, err := time.Parse(time.RFC3339, result.ValidFrom)
+			if err != nil {
+				return prices, errors.New(fmt.Sprintf(OctopusHttpResponseDecodingErrorMessage, err))
+			}
+
+			// Rates without end are valid until further notice, so only one slot is considered
+			resultDuration := p.Resolution()
+			if result.ValidTo != "" {
+				resultEndTime, err := time.Parse(time.RFC3339, result.ValidTo)
+				if err != nil {
+					return prices, errors.New(fmt.Sprintf(OctopusHttpResponseDecodingErrorMessage, err))
+				}
+				resultDuration = resultEndTime.Sub(resultTime)
+			}
+
+			if resultTime.Before(start) || !resultTime.Before(end) {
+				continue
+			}
+
+			resultPrice := result.ValueIncVat
+			if !p.vatInclusive {
+				resultPrice = result.ValueExcVat
+			}
+
+			// Octopus express the prices in pence/kWh
+			prices = append(prices, NewSlot(resultTime.In(p.location), resultDuration, resultPrice/100, p.tariffCode))
+		}
+
+		nextUrl = response.Next
+	}
+
+	SortByTime(prices)
+	return prices, nil
+}
@@ -0,0 +1,150 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newOctopusServer return a fake Octopus server publishing the half-hours of the given range for the given tariff.
+// Results are split into pages of the given size, newest first, as the real one does
+func newOctopusServer(t *testing.T, tariffPath string, start time.Time, end time.Time, pageSize int) *httptest.Server {
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tariffPath || r.URL.Query().Get("period_from") != start.UTC().Format(time.RFC3339) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		page := 1
+		if r.URL.Query().Has("page") {
+			_, _ = fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		}
+
+		response := OctopusResponseSpec{}
+		for instant := end.Add(-30 * time.Minute); !instant.Before(start); instant = instant.Add(-30 * time.Minute) {
+			response.Count++
+			if response.Count <= (page-1)*pageSize || response.Count > page*pageSize {
+				continue
+			}
+
+			// Rates are expressed in pence/kWh, with a VAT of 5%
+			response.Results = append(response.Results, struct {
+				ValueExcVat float64 `json:"value_exc_vat"`
+				ValueIncVat float64 `json:"value_inc_vat"`
+				ValidFrom   string  `json:"valid_from"`
+				ValidTo     string  `json:"valid_to"`
+			}{
+				ValueExcVat: 20,
+				ValueIncVat: 21,
+				ValidFrom:   instant.UTC().Format(time.RFC3339),
+				ValidTo:     instant.Add(30 * time.Minute).UTC().Format(time.RFC3339),
+			})
+		}
+
+		if response.Count > page*pageSize {
+			query := r.URL.Query()
+			query.Set("page", fmt.Sprint(page+1))
+			response.Next = server.URL + r.URL.Path + "?" + query.Encode()
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOctopusPrices(t *testing.T) {
+
+	location, err := time.LoadLocation(OctopusApiTimeLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The last Sunday of March lasts 23 hours in the UK
+	start := time.Date(2026, time.March, 29, 0, 0, 0, 0, location)
+	end := time.Date(2026, time.March, 30, 0, 0, 0, 0, location)
+
+	tests := map[string]struct {
+		vat        string
+		tariffCode string
+		pageSize   int
+
+		expectedTariffPath string
+		expectedPrice      float64
+	}{
+		"tariff crafted from the zone with the vat included": {
+			pageSize:           100,
+			expectedTariffPath: "/products/AGILE-24-10-01/electricity-tariffs/E-1R-AGILE-24-10-01-C/standard-unit-rates/",
+			expectedPrice:      0.21,
+		},
+		"vat excluded": {
+			vat:                OctopusVatExclusive,
+			pageSize:           100,
+			expectedTariffPath: "/products/AGILE-24-10-01/electricity-tariffs/E-1R-AGILE-24-10-01-C/standard-unit-rates/",
+			expectedPrice:      0.20,
+		},
+		"results split into several pages": {
+			vat:                OctopusVatInclusive,
+			tariffCode:         "E-1R-AGILE-24-10-01-A",
+			pageSize:           10,
+			expectedTariffPath: "/products/AGILE-24-10-01/electricity-tariffs/E-1R-AGILE-24-10-01-A/standard-unit-rates/",
+			expectedPrice:      0.21,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newOctopusServer(t, test.expectedTariffPath, start, end, test.pageSize)
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Zone = "c"
+			ctx.Config.Spec.Price.Octopus.URL = server.URL
+			ctx.Config.Spec.Price.Octopus.Vat = test.vat
+			ctx.Config.Spec.Price.Octopus.TariffCode = test.tariffCode
+
+			provider, err := NewOctopusProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(prices) != 46 {
+				t.Fatalf("expected 46 half-hours, got %d", len(prices))
+			}
+
+			for index, item := range prices {
+				expectedStart := start.Add(time.Duration(index) * 30 * time.Minute)
+				if !item.Start.Equal(expectedStart) || item.Duration != 30*time.Minute {
+					t.Errorf("expected half-hour %d starting at %s lasting 30m, got %s lasting %s",
+						index, expectedStart, item.Start, item.Duration)
+				}
+
+				if math.Abs(item.Price-test.expectedPrice) > 1e-9 {
+					t.Errorf("expected the price %g for half-hour %d, got %g", test.expectedPrice, index, item.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestOctopusVatNotSupported(t *testing.T) {
+
+	ctx := newDstContext(time.Now())
+	ctx.Config.Spec.Price.Zone = "C"
+	ctx.Config.Spec.Price.Octopus.Vat = "included"
+
+	if _, err := NewOctopusProvider(ctx); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
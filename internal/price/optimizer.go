@@ -0,0 +1,368 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	//
+	ConstraintsParsingErrorMessage      = "config.device.constraints fields must be durations like 1h30m: %s"
+	ConstraintsMaxCyclesErrorMessage    = "config.device.constraints.maxCyclesPerDay field must be a positive number"
+	ConstraintsNotSatisfiedErrorMessage = "impossible to select slots meeting config.device.constraints in the given range"
+
+	//
+	MinRunDurationCappedMessage = "config.device.constraints.minRunDuration is longer than the active duration, capped to %s"
+	RequireWindowCappedMessage  = "config.device.windows[%d].minDuration is longer than the active duration, capped to %s"
+
+	// Reasons why a slot is selected
+	ReasonCheapest       = "among the cheapest slots"
+	ReasonAlwaysRunBelow = "price below config.price.alwaysRunBelow"
+	ReasonRequireWindow  = "required by config.device.windows"
+
+	// Reasons why the active duration is not covered
+	ReasonMaxPrice    = "active duration shortened by config.price.maxPrice"
+	ReasonUnavailable = "active duration shortened as there are not enough available slots"
+)
+
+// Constraints represents the limits the device must respect when it's turned on and off
+type Constraints struct {
+
+	// Minimum time the device must be kept turned on once started
+	MinRunDuration time.Duration
+
+	// Minimum time the device must be kept turned off between two runs
+	MinOffDuration time.Duration
+
+	// Maximum amount of runs in a planning window, defined on 'device.constraints.maxCyclesPerDay'.
+	// Each window is planned on its own, so it's a calendar day only when 'global.horizon' is disabled.
+	// Zero means unlimited
+	MaxCycles int
+
+	// Ranges of time where the device is allowed, forbidden or required to be turned on
+	Windows []Window
+
+	// Slots above the maximum price are never selected, and slots below the minimum one are always selected.
+	// Nil means no limit
+	MaxPrice       *float64
+	AlwaysRunBelow *float64
+
+	// Price compared against the thresholds: raw or final
+	ThresholdsBasis string
+}
+
+// optimizerState represents the situation of the device after deciding about a slot
+type optimizerState struct {
+	selected time.Duration
+	cycles   int
+	on       bool
+
+	// Time selected inside the current occurrence of a 'require' window. It's capped to its requirement
+	required time.Duration
+
+	// Time the device has been in its current state. It's capped to the minimum duration of that state,
+	// as longer streaks behave the same way
+	streak time.Duration
+}
+
+// optimizerNode represents the cheapest way found to reach a state
+type optimizerNode struct {
+	cost     float64
+	previous optimizerState
+	selected bool
+}
+
+// GetConstraints return the constraints defined on 'device.constraints'
+func GetConstraints(ctx *v1alpha1.Context) (constraints Constraints, err error) {
+
+	constraintsConfig := ctx.Config.Spec.Device.Constraints
+
+	if constraintsConfig.MinRunDuration != "" {
+		constraints.MinRunDuration, err = time.ParseDuration(constraintsConfig.MinRunDuration)
+		if err != nil {
+			return constraints, errors.New(fmt.Sprintf(ConstraintsParsingErrorMessage, err))
+		}
+	}
+
+	if constraintsConfig.MinOffDuration != "" {
+		constraints.MinOffDuration, err = time.ParseDuration(constraintsConfig.MinOffDuration)
+		if err != nil {
+			return constraints, errors.New(fmt.Sprintf(ConstraintsParsingErrorMessage, err))
+		}
+	}
+
+	if constraintsConfig.MaxCyclesPerDay < 0 {
+		return constraints, errors.New(ConstraintsMaxCyclesErrorMessage)
+	}
+	constraints.MaxCycles = constraintsConfig.MaxCyclesPerDay
+
+	constraints.MaxPrice = ctx.Config.Spec.Price.MaxPrice
+	constraints.AlwaysRunBelow = ctx.Config.Spec.Price.AlwaysRunBelow
+
+	constraints.ThresholdsBasis, err = GetThresholdsBasis(ctx)
+	if err != nil {
+		return constraints, err
+	}
+
+	constraints.Windows, err = GetWindows(ctx)
+	return constraints, err
+}
+
+// CapConstraints return the given constraints limited to what can be done with the given active duration.
+// Runs and 'require' windows can not need more time than the active duration, which can be shrunk by the weather
+// scaling, so their minimum durations are capped instead of leaving the device turned off the whole day
+func CapConstraints(ctx *v1alpha1.Context, constraints Constraints, activeDuration time.Duration) Constraints {
+
+	if constraints.MinRunDuration > activeDuration {
+		ctx.Logger.Warnf(MinRunDurationCappedMessage, activeDuration)
+		constraints.MinRunDuration = activeDuration
+	}
+
+	windows := make([]Window, len(constraints.Windows))
+	copy(windows, constraints.Windows)
+
+	for index, window := range windows {
+		if window.Type == WindowTypeRequire && window.MinDuration > activeDuration {
+			ctx.Logger.Warnf(RequireWindowCappedMessage, index, activeDuration)
+			windows[index].MinDuration = activeDuration
+		}
+	}
+
+	constraints.Windows = windows
+	return constraints
+}
+
+// SelectCheapestSlots return the cheapest slots, by final price of the heat delivered, covering the active duration,
+// meeting the given constraints and windows. Slots below 'AlwaysRunBelow' are selected on top of them, without
+// counting toward the active duration, and each
+// selected slot includes the reason of its selection. Given prices must be sorted by time. Selection is done using
+// dynamic programming over the slots, keeping only the cheapest way to reach each state.
+// When the active duration can not be covered, the longest selection is returned.
+// Constraints needing more time than the active duration must be capped before, as done by CapConstraints
+func SelectCheapestSlots(prices SlotList, activeDuration time.Duration, constraints Constraints) (selected SlotList, err error) {
+
+	rules := getSlotRules(prices, constraints)
+
+	// The device is considered turned off for a long time before the first slot
+	initialState := optimizerState{streak: constraints.MinOffDuration}
+	steps := make([]map[optimizerState]optimizerNode, len(prices)+1)
+	steps[0] = map[optimizerState]optimizerNode{initialState: {}}
+
+	for index, item := range prices {
+		steps[index+1] = map[optimizerState]optimizerNode{}
+
+		// Gaps between slots are periods where the device can not be turned on
+		gap := time.Duration(0)
+		if index > 0 && item.Start.After(prices[index-1].End()) {
+			gap = item.Start.Sub(prices[index-1].End())
+		}
+
+		// Occurrences of 'require' windows must be satisfied before leaving them
+		leavingRequirement := -1
+		if index > 0 && rules.requirement[index-1] != rules.requirement[index] {
+			leavingRequirement = rules.requirement[index-1]
+		}
+
+		for _, state := range sortedOptimizerStates(steps[index]) {
+			node := steps[index][state]
+			previousState := state
+
+			if leavingRequirement != -1 {
+				if state.required < rules.requirements[leavingRequirement] {
+					continue
+				}
+				state.required = 0
+			}
+
+			if gap > 0 {
+				if state.on && state.streak < constraints.MinRunDuration {
+					continue
+				}
+				state = constraints.turnOff(state, gap)
+			}
+
+			// Keep the device turned off during the slot
+			if !rules.forced[index] && (!state.on || state.streak >= constraints.MinRunDuration) {
+				nextState := constraints.turnOff(state, item.Duration)
+				relaxOptimizerState(steps[index+1], nextState, optimizerNode{
+					cost: node.cost, previous: previousState, selected: false,
+				})
+			}
+
+			// Keep the device turned on during the slot. Once covered, only forced slots are selected
+			if (state.selected >= activeDuration && !rules.forced[index]) || !rules.allowed[index] {
+				continue
+			}
+
+			nextState, allowed := constraints.turnOn(state, item.Duration, rules.forced[index])
+			if !allowed {
+				continue
+			}
+
+			if rules.requirement[index] != -1 {
+				nextState.required = minDuration(nextState.required+item.Duration, rules.requirements[rules.requirement[index]])
+			}
+
+			relaxOptimizerState(steps[index+1], nextState, optimizerNode{
+				cost: node.cost + item.HeatPrice()*item.Duration.Hours(), previous: previousState, selected: true,
+			})
+		}
+	}
+
+	// Look for the best final state. Runs must be long enough even at the end
+	finalStates := sortedOptimizerStates(steps[len(prices)])
+	bestStateFound := false
+	var bestState optimizerState
+
+	for _, state := range finalStates {
+		if state.on && state.streak < constraints.MinRunDuration {
+			continue
+		}
+
+		if len(prices) > 0 && rules.requirement[len(prices)-1] != -1 &&
+			state.required < rules.requirements[rules.requirement[len(prices)-1]] {
+			continue
+		}
+
+		if !bestStateFound || isBetterOptimizerState(steps[len(prices)], state, bestState, activeDuration) {
+			bestState = state
+			bestStateFound = true
+		}
+	}
+
+	if !bestStateFound {
+		return selected, errors.New(ConstraintsNotSatisfiedErrorMessage)
+	}
+
+	// Walk the path back to know the selected slots
+	state := bestState
+	for index := len(prices); index > 0; index-- {
+		node := steps[index][state]
+		if node.selected {
+			item := prices[index-1]
+
+			switch {
+			case rules.forced[index-1]:
+				item.Reason = ReasonAlwaysRunBelow
+			case rules.requirement[index-1] != -1 && rules.requirements[rules.requirement[index-1]] > 0:
+				item.Reason = ReasonRequireWindow
+			default:
+				item.Reason = ReasonCheapest
+			}
+
+			selected = append(selected, item)
+		}
+		state = node.previous
+	}
+
+	SortByTime(selected)
+	return selected, nil
+}
+
+// turnOff return the state after keeping the device turned off for the given duration
+func (c Constraints) turnOff(state optimizerState, duration time.Duration) optimizerState {
+
+	if state.on {
+		state.on = false
+		state.streak = 0
+	}
+
+	state.streak = minDuration(state.streak+duration, c.MinOffDuration)
+	return state
+}
+
+// turnOn return the state after keeping the device turned on for the given duration,
+// and whether the constraints allow it. Forced slots ignore the minimum off duration and the cycles limit,
+// and they are not counted as selected time, as they run on top of the active duration
+func (c Constraints) turnOn(state optimizerState, duration time.Duration, forced bool) (optimizerState, bool) {
+
+	if !state.on {
+		if state.streak < c.MinOffDuration && !forced {
+			return state, false
+		}
+
+		// Cycles are only counted when limited, to keep the amount of states low
+		if c.MaxCycles > 0 {
+			if state.cycles >= c.MaxCycles && !forced {
+				return state, false
+			}
+			state.cycles++
+		}
+
+		state.on = true
+		state.streak = 0
+	}
+
+	if !forced {
+		state.selected += duration
+	}
+
+	state.streak = minDuration(state.streak+duration, c.MinRunDuration)
+	return state, true
+}
+
+// relaxOptimizerState store the node for the given state when it's cheaper than the known one
+func relaxOptimizerState(states map[optimizerState]optimizerNode, state optimizerState, node optimizerNode) {
+
+	knownNode, found := states[state]
+	if !found || node.cost < knownNode.cost {
+		states[state] = node
+	}
+}
+
+// isBetterOptimizerState return true when the candidate state is preferred over the best one.
+// States covering the active duration are preferred, then the longer ones, then the cheaper ones
+func isBetterOptimizerState(states map[optimizerState]optimizerNode, candidate optimizerState, best optimizerState,
+	activeDuration time.Duration) bool {
+
+	candidateCovered := candidate.selected >= activeDuration
+	bestCovered := best.selected >= activeDuration
+
+	if candidateCovered != bestCovered {
+		return candidateCovered
+	}
+
+	if !candidateCovered && candidate.selected != best.selected {
+		return candidate.selected > best.selected
+	}
+
+	return states[candidate].cost < states[best].cost
+}
+
+// sortedOptimizerStates return the states of the given map in a stable order, so ties are always solved the same way
+func sortedOptimizerStates(states map[optimizerState]optimizerNode) (result []optimizerState) {
+
+	for state := range states {
+		result = append(result, state)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].selected != result[j].selected {
+			return result[i].selected < result[j].selected
+		}
+		if result[i].cycles != result[j].cycles {
+			return result[i].cycles < result[j].cycles
+		}
+		if result[i].on != result[j].on {
+			return !result[i].on
+		}
+		if result[i].required != result[j].required {
+			return result[i].required < result[j].required
+		}
+		return result[i].streak < result[j].streak
+	})
+
+	return result
+}
+
+// minDuration return the lowest of the given durations
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,121 @@
+package price
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// getHourlySlots return consecutive slots of one hour with the given prices, starting at the given moment
+func getHourlySlots(start time.Time, prices ...float64) (slots SlotList) {
+
+	for index, price := range prices {
+		slots = append(slots, Slot{
+			Start:      start.Add(time.Duration(index) * time.Hour),
+			Duration:   time.Hour,
+			Price:      price,
+			FinalPrice: price,
+		})
+	}
+
+	return slots
+}
+
+func TestSelectCheapestSlots(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	maxPrice := 0.25
+	alwaysRunBelow := 0.02
+
+	tests := map[string]struct {
+		prices         []float64
+		activeDuration time.Duration
+		constraints    Constraints
+
+		// Hours selected, as offsets from the start. Ignored when an error is expected
+		expected    []int
+		expectError bool
+	}{
+		"cheapest hours without constraints": {
+			prices:         []float64{0.05, 0.30, 0.06, 0.30, 0.07, 0.30},
+			activeDuration: 3 * time.Hour,
+			expected:       []int{0, 2, 4},
+		},
+		"minimum run duration keeps the hours together": {
+			prices:         []float64{0.05, 0.30, 0.06, 0.30, 0.07, 0.30},
+			activeDuration: 3 * time.Hour,
+			constraints:    Constraints{MinRunDuration: 2 * time.Hour},
+			expected:       []int{0, 1, 2},
+		},
+		"minimum off duration separates the runs": {
+			prices:         []float64{0.05, 0.06, 0.30, 0.07, 0.08, 0.30},
+			activeDuration: 4 * time.Hour,
+			constraints:    Constraints{MinOffDuration: 2 * time.Hour},
+			expected:       []int{0, 1, 2, 3},
+		},
+		"maximum cycles limit the runs": {
+			prices:         []float64{0.05, 0.30, 0.06, 0.30, 0.07, 0.30},
+			activeDuration: 3 * time.Hour,
+			constraints:    Constraints{MaxCycles: 1},
+			expected:       []int{0, 1, 2},
+		},
+		"maximum price shortens the selection": {
+			prices:         []float64{0.05, 0.30, 0.06, 0.30, 0.07, 0.30},
+			activeDuration: 4 * time.Hour,
+			constraints:    Constraints{MaxPrice: &maxPrice},
+			expected:       []int{0, 2, 4},
+		},
+		"forced hours run on top of the active duration": {
+			prices:         []float64{0.01, 0.30, 0.05, 0.30, 0.06, 0.30},
+			activeDuration: 2 * time.Hour,
+			constraints:    Constraints{AlwaysRunBelow: &alwaysRunBelow},
+			expected:       []int{0, 2, 4},
+		},
+		"forced hour too short for the minimum run duration": {
+			prices:         []float64{0.30, 0.30, 0.01},
+			activeDuration: 3 * time.Hour,
+			constraints: Constraints{
+				MinRunDuration: 3 * time.Hour, MaxPrice: &maxPrice, AlwaysRunBelow: &alwaysRunBelow,
+			},
+			expectError: true,
+		},
+		"require windows too far apart for the minimum run duration": {
+			prices:         []float64{0.05, 0.30, 0.06, 0.30},
+			activeDuration: 2 * time.Hour,
+			constraints: Constraints{
+				MinRunDuration: 2 * time.Hour,
+				Windows: []Window{
+					{Type: WindowTypeRequire, Start: 0, End: time.Hour, MinDuration: time.Hour},
+					{Type: WindowTypeRequire, Start: 2 * time.Hour, End: 3 * time.Hour, MinDuration: time.Hour},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			selected, err := SelectCheapestSlots(getHourlySlots(start, test.prices...), test.activeDuration, test.constraints)
+
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the selection %v", selected)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var hours []int
+			for _, item := range selected {
+				hours = append(hours, int(item.Start.Sub(start)/time.Hour))
+			}
+
+			if !reflect.DeepEqual(hours, test.expected) {
+				t.Errorf("expected the hours %v, got %v", test.expected, hours)
+			}
+		})
+	}
+}
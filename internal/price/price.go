@@ -1,17 +1,14 @@
 // ATTENTION:
-// This package uses ApagaLuz as a datasource for PVPC. This datasource uses official ESIOS' API from the spanish
-// government, which return data using CEST timezone. As a consequence, all this package works using CEST timezone
-// and the other packages must take this into account to convert it and do whatever they need.
-// [ESIOS] Ref: https://www.esios.ree.es/es/pvpc
+// This package is not aware of where the prices come from. They are retrieved through the PriceProvider selected
+// on config, and every provider express them in its own timezone. As a consequence, the other packages must take
+// this into account to convert the schedules and do whatever they need.
 
 package price
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"slices"
 	"sort"
 	"time"
 
@@ -19,201 +16,405 @@ import (
 )
 
 const (
-	ApagaLuzAPIUrl       = "https://raw.githubusercontent.com/jorgeatgu/apaga-luz/main/public/data/today_price.json"
-	ApagaLuzCanaryAPIUrl = "https://raw.githubusercontent.com/jorgeatgu/apaga-luz/main/public/data/canary_price.json"
-
-	// Location of retrieved data from ApagaLuz
-	ApagaLuzApiTimeLocation       = "Europe/Madrid"
-	ApagaLuzCanaryApiTimeLocation = "Atlantic/Canary"
-
 	//
-	dateLayout = "02/01/2006 15"
+	MaxActiveDuration = 24 * time.Hour
 
 	//
-	ActiveHoursOutOfRangeErrorMessage = "config.device.activeHours field must be a number between 1 and 24"
+	ActiveDurationOutOfRangeErrorMessage = "config.device.activeDuration field must be a duration between 1m and 24h. " +
+		"For config.device.activeHours field, a number between 1 and 24"
+	ActiveDurationParsingErrorMessage = "config.device.activeDuration field must be a duration like 2h45m: %s"
 
 	//
-	PricesNotAvailableErrorMessage = "impossible to get prices from ApagaLuz API"
+	PricesNotAvailableErrorMessage = "impossible to get prices from the price provider"
+	PricesIncompleteErrorMessage   = "prices are not published yet for the whole range"
+)
+
+var (
+	// ErrPricesIncomplete is returned when the provider does not know the prices until the end of the requested range
+	ErrPricesIncomplete = errors.New(PricesIncompleteErrorMessage)
 )
 
 // Schedule represents a time range to start and stop an external device
 type Schedule struct {
-	Start time.Time
-	Stop  time.Time
+	Start time.Time `json:"start"`
+	Stop  time.Time `json:"stop"`
+
+	// Reasons why the range was chosen
+	Reasons []string `json:"reasons,omitempty"`
 }
 
-// HourData represents each of the individual items in the response retrieved from ApagaLuz API
-type HourData struct {
-	Day   string  `json:"day"`
-	Hour  int     `json:"hour"`
-	Price float64 `json:"price"`
-	Zone  string  `json:"zone"`
+// Slot represents the price of the electricity for a slot of time, no matter its duration
+type Slot struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Price    float64       `json:"price"`
+	Zone     string        `json:"zone"`
+
+	// Price including taxes, tolls and surcharges defined on 'price.composition'.
+	// It's the same as the raw price when no composition is defined
+	FinalPrice float64 `json:"-"`
+
+	// Heat delivered by each unit of energy consumed during the slot, as defined on 'device.cop'.
+	// It's 1 when the efficiency of the device is not taken into account
+	Efficiency float64 `json:"-"`
+
+	// Reason why the slot was selected. Only filled on selected slots
+	Reason string `json:"-"`
 }
 
-// HourDataList represents the entire response retrieved from ApagaLuz API
-type HourDataList []HourData
+// SlotList represents a list of prices for several slots of time
+type SlotList []Slot
+
+// NewSlot return the price for the slot starting at the given moment
+func NewSlot(start time.Time, duration time.Duration, price float64, zone string) Slot {
+	return Slot{
+		Start:      start,
+		Duration:   duration,
+		Price:      price,
+		Zone:       zone,
+		FinalPrice: price,
+		Efficiency: 1,
+	}
+}
 
-// GetApiData TODO
-func GetApiData(ctx *v1alpha1.Context) (response *HourDataList, err error) {
+// PriceFor return the price of the slot for the given basis: raw or final
+func (s Slot) PriceFor(basis string) float64 {
+	if basis == PriceBasisRaw {
+		return s.Price
+	}
+	return s.FinalPrice
+}
 
-	// Send the request and wait for the result
-	dataApiUrl := ApagaLuzAPIUrl
-	if ctx.Config.Spec.Price.Zone == "canaryislands" {
-		dataApiUrl = ApagaLuzCanaryAPIUrl
+// HeatPrice return the final price of each unit of heat delivered during the slot
+func (s Slot) HeatPrice() float64 {
+	if s.Efficiency <= 0 {
+		return s.FinalPrice
 	}
-	resp, err := http.Get(dataApiUrl)
-	if err != nil {
-		return response, err
+	return s.FinalPrice / s.Efficiency
+}
+
+// End return the moment when the slot finishes
+func (s Slot) End() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// SortByTime sort the given prices by the moment their slots start, from the oldest to the newest
+func SortByTime(prices SlotList) {
+	sort.SliceStable(prices, func(i, j int) bool {
+		return prices[i].Start.Before(prices[j].Start)
+	})
+}
+
+// CoversRange return true when the given prices, sorted by time, are known from 'start' until 'end'
+func CoversRange(prices SlotList, start time.Time, end time.Time) bool {
+
+	if len(prices) == 0 {
+		return false
 	}
-	defer resp.Body.Close()
 
-	// Read the request's body
-	body, err := io.ReadAll(resp.Body)
+	return !prices[0].Start.After(start) && !prices[len(prices)-1].End().Before(end)
+}
+
+// GetActiveDuration return the time the device must be turned on, defined on 'device.activeDuration'.
+// For compatibility, 'device.activeHours' is used when the former is not defined
+func GetActiveDuration(ctx *v1alpha1.Context) (activeDuration time.Duration, err error) {
+
+	activeDuration = time.Duration(ctx.Config.Spec.Device.ActiveHours) * time.Hour
+
+	if ctx.Config.Spec.Device.ActiveDuration != "" {
+		activeDuration, err = time.ParseDuration(ctx.Config.Spec.Device.ActiveDuration)
+		if err != nil {
+			return activeDuration, errors.New(fmt.Sprintf(ActiveDurationParsingErrorMessage, err))
+		}
+	}
+
+	if activeDuration < time.Minute || activeDuration > MaxActiveDuration {
+		return activeDuration, errors.New(ActiveDurationOutOfRangeErrorMessage)
+	}
+
+	return activeDuration, nil
+}
+
+// GetApiData return the prices for the slots in the range [start, end) retrieved from the given provider
+func GetApiData(ctx *v1alpha1.Context, provider PriceProvider, start time.Time, end time.Time) (response *SlotList, err error) {
+
+	prices, err := provider.GetPrices(start, end)
 	if err != nil {
 		return response, err
 	}
 
-	// Decode response's JSON into a struct
-	err = json.Unmarshal(body, &response)
+	SortByTime(prices)
+
+	// Prices must be known until the end of the range. Otherwise, they are not published yet
+	if len(prices) == 0 || prices[len(prices)-1].End().Before(end) {
+		return response, ErrPricesIncomplete
+	}
 
-	// Discard passed hours when requested by config
+	// Discard passed slots when requested by config
 	if ctx.Config.Spec.Global.IgnorePassedHours {
-		currentHour := time.Now().In(time.Local).Hour()
-		*response = (*response)[currentHour:24]
+		currentTime := ctx.Clock.Now()
+		remainingPrices := SlotList{}
+
+		for _, item := range prices {
+			if item.End().After(currentTime) {
+				remainingPrices = append(remainingPrices, item)
+			}
+		}
+		prices = remainingPrices
 	}
 
+	response = &prices
 	return response, err
 }
 
 // TODO
-func GetApiDataByPrice(ctx *v1alpha1.Context) (response *HourDataList, err error) {
+func GetApiDataByPrice(ctx *v1alpha1.Context, provider PriceProvider, start time.Time, end time.Time) (response *SlotList, err error) {
 
-	response, err = GetApiData(ctx)
+	response, err = GetApiData(ctx, provider, start, end)
 	if err != nil {
 		return response, err
 	}
 
-	sort.Slice(*response, func(i, j int) bool {
-		return (*response)[i].Price < (*response)[j].Price
+	sort.SliceStable(*response, func(i, j int) bool {
+		return (*response)[i].FinalPrice < (*response)[j].FinalPrice
 	})
 
 	return response, nil
 }
 
-// GetLimitedCorrelativeHourRanges return an array whose elements are lists of correlative hours.
-// Those hours were previously sorted and selected by having the lowest price as criteria
-func GetLimitedCorrelativeHourRanges(ctx *v1alpha1.Context) (correlativeRanges []HourDataList, err error) {
+// GetLimitedCorrelativeSlotRanges return an array whose elements are lists of correlative slots from the given prices,
+// sorted by time. Those slots were previously selected by having the lowest price as criteria, meeting 'device.constraints'.
+// Reasons why the active duration is not fully covered are returned too
+func GetLimitedCorrelativeSlotRanges(ctx *v1alpha1.Context, prices SlotList, activeDuration time.Duration) (correlativeRanges []SlotList, shortenedReasons []string, err error) {
 
-	// 1. Get all the data sorted by price
-	response, err := GetApiDataByPrice(ctx)
+	// 1. Get the constraints to meet
+	constraints, err := GetConstraints(ctx)
 	if err != nil {
-		return correlativeRanges, err
+		return correlativeRanges, shortenedReasons, err
 	}
+	constraints = CapConstraints(ctx, constraints, activeDuration)
 
-	// Check desired amount of hours. Must be between 0 than 24
-	if ctx.Config.Spec.Device.ActiveHours < 1 || ctx.Config.Spec.Device.ActiveHours > 24 {
-		return correlativeRanges, errors.New(ActiveHoursOutOfRangeErrorMessage)
+	// Remember whether some slots are discarded by the price ceiling, to explain shorter selections
+	discardedByMaxPrice := false
+	for _, item := range prices {
+		if constraints.MaxPrice != nil && item.PriceFor(constraints.ThresholdsBasis) > *constraints.MaxPrice {
+			discardedByMaxPrice = true
+		}
 	}
 
-	// Limit 'device.activeHours' to the available amount of remaining hours
-	parsedActiveHours := ctx.Config.Spec.Device.ActiveHours
-	if ctx.Config.Spec.Device.ActiveHours > len(*response) {
-		parsedActiveHours = len(*response)
+	// 2. Select the cheapest slots covering the active duration, discard the others.
+	// It's limited to the available amount of remaining slots
+	selectedSlots, err := SelectCheapestSlots(prices, activeDuration, constraints)
+	if err != nil {
+		return correlativeRanges, shortenedReasons, err
 	}
 
-	// 2. Keep the N cheapest items, discard the others
-	*response = (*response)[0:parsedActiveHours]
+	// Slots forced by 'price.alwaysRunBelow' run on top of the active duration, so they do not cover it
+	selectedDuration := time.Duration(0)
+	for _, item := range selectedSlots {
+		if item.Reason != ReasonAlwaysRunBelow {
+			selectedDuration += item.Duration
+		}
+	}
 
-	// 3. Re-sort them by hour
-	sort.Slice(*response, func(i, j int) bool {
-		return (*response)[i].Hour < (*response)[j].Hour
-	})
+	if selectedDuration < activeDuration && discardedByMaxPrice {
+		shortenedReasons = append(shortenedReasons, ReasonMaxPrice)
+	} else if selectedDuration < activeDuration {
+		shortenedReasons = append(shortenedReasons, ReasonUnavailable)
+	}
 
-	// 4. Craft an array whose elements are lists of correlative hours
+	// 3. Selected slots are already sorted by time, as the range can cover several days
+
+	// 4. Craft an array whose elements are lists of correlative slots
 	correlativeRangesIndex := 0
 
-	for index, item := range *response {
+	for index, item := range selectedSlots {
 
-		// Add the first hour data to a new range directly
+		// Add the first slot to a new range directly
 		if index == 0 {
-			correlativeRanges = append(correlativeRanges, HourDataList{})
+			correlativeRanges = append(correlativeRanges, SlotList{})
 			correlativeRanges[correlativeRangesIndex] = append(correlativeRanges[correlativeRangesIndex], item)
 			continue
 		}
 
-		// Get the previous element to compare if their hours are correlatives.
+		// Get the previous element to compare if their slots are correlatives, no matter their duration.
 		// On correlatives, add current item to the same list of correlatives. If not, it's added in a new range
-		previousItem := HourData{}
-		previousItem = (*response)[index-1]
+		previousItem := selectedSlots[index-1]
 
-		if item.Hour == (previousItem.Hour+1) || item.Hour == (previousItem.Hour-1) {
+		if previousItem.End().Equal(item.Start) {
 			correlativeRanges[correlativeRangesIndex] = append(correlativeRanges[correlativeRangesIndex], item)
 		} else {
 			correlativeRangesIndex++
-			correlativeRanges = append(correlativeRanges, HourDataList{})
+			correlativeRanges = append(correlativeRanges, SlotList{})
 
 			correlativeRanges[correlativeRangesIndex] = append(correlativeRanges[correlativeRangesIndex], item)
 		}
 	}
 
-	return correlativeRanges, err
+	return correlativeRanges, shortenedReasons, err
+}
+
+// GetBestSchedules return a list of schedules in the range [start, end) that keep the device turned on for the given
+// active duration. Schedules use the exact boundaries of the selected slots, so the device is turned on for the whole
+// active duration. The estimated cost of the schedules, compared to a baseline, is returned too.
+// Given temperatures are used to weight the prices by 'device.cop', when it's enabled
+func GetBestSchedules(ctx *v1alpha1.Context, start time.Time, end time.Time, activeDuration time.Duration,
+	temperatures []Temperature) (schedules []Schedule, estimation CostEstimation, err error) {
+	return getBestSchedules(ctx, start, end, activeDuration, temperatures, nil)
+}
+
+// GetTopUpSchedules return the best schedules to cover the given duration in the range [start, end),
+// without overlapping the given planned schedules. It's used to complete plans that were not fully executed
+func GetTopUpSchedules(ctx *v1alpha1.Context, start time.Time, end time.Time, duration time.Duration,
+	temperatures []Temperature, planned []Schedule) (schedules []Schedule, err error) {
+
+	schedules, _, err = getBestSchedules(ctx, start, end, duration, temperatures, planned)
+	return schedules, err
 }
 
-// GetBestSchedules return a list of schedules that meet 'active hours' config parameter
-// Starts are delayed by 5 minutes, and stops are 5 minutes early. Done in purpose to avoid time collisions on
-// parallel scheduling. This can be improved a lot. Are you willing to contribute?
-func GetBestSchedules(ctx *v1alpha1.Context) (schedules []Schedule, err error) {
+// getBestSchedules return the best schedules to cover the active duration in the range [start, end),
+// discarding the slots overlapping the excluded schedules
+func getBestSchedules(ctx *v1alpha1.Context, start time.Time, end time.Time, activeDuration time.Duration,
+	temperatures []Temperature, excluded []Schedule) (schedules []Schedule, estimation CostEstimation, err error) {
+
+	provider, err := NewPriceProvider(ctx)
+	if err != nil {
+		return schedules, estimation, err
+	}
 
-	limitedCorrelativeRanges, err := GetLimitedCorrelativeHourRanges(ctx)
+	// Get all the data sorted by time
+	response, err := GetApiData(ctx, provider, start, end)
 	if err != nil {
-		return schedules, err
+		return schedules, estimation, err
 	}
 
-	// Data from API is coming located. It's needed to parse it in that way
-	apiTimeLocation, err := time.LoadLocation(ApagaLuzApiTimeLocation)
-	if ctx.Config.Spec.Price.Zone == "canaryislands" {
-		apiTimeLocation, err = time.LoadLocation(ApagaLuzCanaryApiTimeLocation)
+	// Discarded slots are seen as gaps, so the device is kept turned off during them
+	if len(excluded) > 0 {
+		remainingPrices := SlotList{}
+		for _, item := range *response {
+			if !overlapsSchedules(item, excluded) {
+				remainingPrices = append(remainingPrices, item)
+			}
+		}
+		response = &remainingPrices
 	}
 
+	// Weight the prices by the efficiency of the device at the forecast temperature
+	if ctx.Config.Spec.Device.Cop.Enabled {
+		err = ApplyCopCurve(ctx, *response, temperatures)
+		if err != nil {
+			ctx.Logger.Infof(CopNotAppliedMessage, err)
+		}
+	}
+
+	limitedCorrelativeRanges, shortenedReasons, err := GetLimitedCorrelativeSlotRanges(ctx, *response, activeDuration)
+	if err != nil {
+		return schedules, estimation, err
+	}
+
+	// Slots forced by 'price.alwaysRunBelow' are executed completely, on top of the active duration
+	forcedDuration := time.Duration(0)
+
 	for _, rangeItem := range limitedCorrelativeRanges {
 
-		// Sort by hour (minor to major)
-		sort.Slice(rangeItem, func(i, j int) bool {
-			return rangeItem[i].Hour < rangeItem[j].Hour
-		})
+		// Ranges are sorted by time, so they start on the first slot and stop at the end of the last one
+		schedule := Schedule{
+			Start: rangeItem[0].Start,
+			Stop:  rangeItem[len(rangeItem)-1].End(),
+		}
 
-		// Get timestamp for the first item (always present)
-		startTime, err := time.ParseInLocation(dateLayout, fmt.Sprintf("%s %d", rangeItem[0].Day, rangeItem[0].Hour), apiTimeLocation)
-		if err != nil {
-			return schedules, err
+		for _, item := range rangeItem {
+			if item.Reason == ReasonAlwaysRunBelow {
+				forcedDuration += item.Duration
+			}
+
+			if !slices.Contains(schedule.Reasons, item.Reason) {
+				schedule.Reasons = append(schedule.Reasons, item.Reason)
+			}
 		}
 
-		startTime = startTime.Add(5 * time.Minute)
+		schedule.Reasons = append(schedule.Reasons, shortenedReasons...)
+		schedules = append(schedules, schedule)
+	}
 
-		var stopTime time.Time
+	// Constraints were already validated when selecting the slots
+	constraints, _ := GetConstraints(ctx)
+	trimSchedules(limitedCorrelativeRanges, schedules, activeDuration+forcedDuration,
+		minDuration(constraints.MinRunDuration, activeDuration))
 
-		// Only one item: in 1 hour stop it
-		if len(rangeItem) == 1 {
-			stopTime = startTime.Add(50 * time.Minute).In(apiTimeLocation)
-			goto appendSchedule
+	// Estimations are only informative, so the schedules are kept when they fail
+	estimation, err = GetCostEstimation(ctx, *response, schedules, start)
+	if err != nil {
+		ctx.Logger.Warnf(CostEstimationFailedMessage, err)
+	}
+
+	return schedules, estimation, nil
+}
+
+// overlapsSchedules return true when the given slot overlaps any of the given schedules
+func overlapsSchedules(item Slot, schedules []Schedule) bool {
+
+	for _, schedule := range schedules {
+		if item.Start.Before(schedule.Stop) && item.End().After(schedule.Start) {
+			return true
 		}
+	}
 
-		// More items: stop it on last item's timestamp
-		stopTime, err = time.ParseInLocation(dateLayout, fmt.Sprintf("%s %d", rangeItem[len(rangeItem)-1].Day, rangeItem[len(rangeItem)-1].Hour), apiTimeLocation)
-		if err != nil {
-			return schedules, err
+	return false
+}
+
+// trimSchedules shorten the given schedules, crafted from the given ranges, when the selected slots cover more time
+// than the active duration. This happens when the duration is not a multiple of the slots' duration, so only the
+// leftover of a single slot is removed. It's removed from the most expensive edge selected for being among the
+// cheapest slots, as slots forced by thresholds or required by windows are executed completely.
+// Runs are never shortened under the given minimum run duration. When no edge can be trimmed, schedules are kept
+func trimSchedules(correlativeRanges []SlotList, schedules []Schedule, activeDuration time.Duration,
+	minRunDuration time.Duration) {
+
+	selectedDuration := time.Duration(0)
+	for _, schedule := range schedules {
+		selectedDuration += schedule.Stop.Sub(schedule.Start)
+	}
+
+	excessDuration := selectedDuration - activeDuration
+	if excessDuration <= 0 {
+		return
+	}
+
+	// Look for the most expensive edge that can absorb the excess without disappearing
+	trimRange, trimStart := -1, false
+	var trimPrice float64
+
+	for rangeIndex, rangeItem := range correlativeRanges {
+		scheduleDuration := schedules[rangeIndex].Stop.Sub(schedules[rangeIndex].Start)
+		if scheduleDuration-excessDuration < minRunDuration {
+			continue
 		}
 
-		stopTime = stopTime.Add(55 * time.Minute)
+		for _, edgeIsStart := range []bool{true, false} {
+			edge := rangeItem[len(rangeItem)-1]
+			if edgeIsStart {
+				edge = rangeItem[0]
+			}
 
-		//
-	appendSchedule:
+			if edge.Reason != ReasonCheapest || excessDuration >= edge.Duration {
+				continue
+			}
 
-		schedules = append(schedules, Schedule{
-			Start: startTime,
-			Stop:  stopTime,
-		})
+			if trimRange == -1 || edge.HeatPrice() > trimPrice {
+				trimRange, trimStart, trimPrice = rangeIndex, edgeIsStart, edge.HeatPrice()
+			}
+		}
 	}
 
-	return schedules, err
+	if trimRange == -1 {
+		return
+	}
+
+	if trimStart {
+		schedules[trimRange].Start = schedules[trimRange].Start.Add(excessDuration)
+		return
+	}
+
+	schedules[trimRange].Stop = schedules[trimRange].Stop.Add(-excessDuration)
 }
@@ -0,0 +1,135 @@
+package price
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+	"go.uber.org/zap"
+)
+
+// newFilePricesContext return a context whose prices are read from a CSV file with the given hourly prices,
+// starting at the given moment
+func newFilePricesContext(t *testing.T, start time.Time, prices []float64) *v1alpha1.Context {
+
+	lines := []string{"time,price"}
+	for index, price := range prices {
+		lines = append(lines, fmt.Sprintf("%s,%g", start.Add(time.Duration(index)*time.Hour).Format(time.RFC3339), price))
+	}
+
+	pricesPath := filepath.Join(t.TempDir(), "prices.csv")
+	err := os.WriteFile(pricesPath, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &v1alpha1.Context{
+		Config: &v1alpha1.ConfigSpec{},
+		Logger: zap.NewNop().Sugar(),
+		Clock:  clocktest.NewFakeClock(start),
+	}
+
+	ctx.Config.Spec.Price.Provider = ProviderFile
+	ctx.Config.Spec.Price.File.Path = pricesPath
+	ctx.Config.Spec.Price.File.Mapping.Fields.Timestamp = "time"
+	ctx.Config.Spec.Price.File.Mapping.Fields.Price = "price"
+
+	return ctx
+}
+
+// getDayPrices return the prices for 24 hours, using the given ones for the first hours and 0.20 for the rest
+func getDayPrices(firstPrices ...float64) (prices []float64) {
+
+	prices = append(prices, firstPrices...)
+	for len(prices) < 24 {
+		prices = append(prices, 0.20)
+	}
+
+	return prices
+}
+
+// assertSchedules check that the given schedules cover the expected ranges, expressed as offsets from the start
+func assertSchedules(t *testing.T, schedules []Schedule, start time.Time, expected ...[2]time.Duration) {
+
+	if len(schedules) != len(expected) {
+		t.Fatalf("expected %d schedules, got %d: %v", len(expected), len(schedules), schedules)
+	}
+
+	for index, offsets := range expected {
+		if !schedules[index].Start.Equal(start.Add(offsets[0])) || !schedules[index].Stop.Equal(start.Add(offsets[1])) {
+			t.Errorf("expected schedule from %s to %s, got from %s to %s", start.Add(offsets[0]), start.Add(offsets[1]),
+				schedules[index].Start, schedules[index].Stop)
+		}
+	}
+}
+
+func TestBestSchedulesTrim(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	alwaysRunBelow := 0.02
+
+	tests := map[string]struct {
+		prices         []float64
+		activeDuration time.Duration
+		constraints    v1alpha1.DeviceConstraintsSpec
+		windows        []v1alpha1.DeviceWindowSpec
+		alwaysRunBelow *float64
+		expected       [][2]time.Duration
+	}{
+		"leftover of the most expensive slot": {
+			prices:         getDayPrices(0.20, 0.20, 0.05, 0.07, 0.06),
+			activeDuration: 2*time.Hour + 30*time.Minute,
+			expected:       [][2]time.Duration{{2 * time.Hour, 4*time.Hour + 30*time.Minute}},
+		},
+		"required and forced slots are not trimmed": {
+			prices:         getDayPrices(0.30, 0.30, 0.10, 0.10, 0.01),
+			activeDuration: 2 * time.Hour,
+			windows: []v1alpha1.DeviceWindowSpec{
+				{Type: WindowTypeRequire, Start: "00:00", End: "02:00", MinDuration: "2h"},
+			},
+			alwaysRunBelow: &alwaysRunBelow,
+			expected:       [][2]time.Duration{{0, 2 * time.Hour}, {4 * time.Hour, 5 * time.Hour}},
+		},
+		"forced slots do not consume the active duration": {
+			prices:         getDayPrices(0.01, 0.30, 0.05, 0.07, 0.30),
+			activeDuration: time.Hour + 30*time.Minute,
+			alwaysRunBelow: &alwaysRunBelow,
+			expected:       [][2]time.Duration{{0, time.Hour}, {2 * time.Hour, 3*time.Hour + 30*time.Minute}},
+		},
+		"runs are not trimmed under the minimum run duration": {
+			prices:         getDayPrices(0.05, 0.06, 0.30, 0.30, 0.07, 0.08),
+			activeDuration: 3*time.Hour + 30*time.Minute,
+			constraints:    v1alpha1.DeviceConstraintsSpec{MinRunDuration: "2h"},
+			expected:       [][2]time.Duration{{0, 2 * time.Hour}, {4 * time.Hour, 6 * time.Hour}},
+		},
+		"require windows longer than the active duration are capped": {
+			prices:         getDayPrices(0.30, 0.10, 0.20, 0.10, 0.30, 0.01),
+			activeDuration: 2 * time.Hour,
+			windows: []v1alpha1.DeviceWindowSpec{
+				{Type: WindowTypeRequire, Start: "00:00", End: "05:00", MinDuration: "4h"},
+			},
+			expected: [][2]time.Duration{{time.Hour, 2 * time.Hour}, {3 * time.Hour, 4 * time.Hour}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newFilePricesContext(t, start, test.prices)
+			ctx.Config.Spec.Device.Constraints = test.constraints
+			ctx.Config.Spec.Device.Windows = test.windows
+			ctx.Config.Spec.Price.AlwaysRunBelow = test.alwaysRunBelow
+
+			schedules, _, err := GetBestSchedules(ctx, start, start.Add(24*time.Hour), test.activeDuration, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertSchedules(t, schedules, start, test.expected...)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Names of the providers available to be selected on 'price.provider'
+	ProviderApagaLuz = "apagaluz"
+	ProviderEsios    = "esios"
+	ProviderEntsoe   = "entsoe"
+	ProviderNordPool = "nordpool"
+	ProviderTibber   = "tibber"
+	ProviderAwattar  = "awattar"
+	ProviderOctopus  = "octopus"
+	ProviderTou      = "tou"
+	ProviderFile     = "file"
+	ProviderGeneric  = "genericHttp"
+
+	//
+	ProviderSkippedMessage = "price provider '%s' can not be built, skipped from config.price.providers: %s"
+
+	//
+	ProviderNotSupportedErrorMessage = "price provider '%s' is not supported"
+	NoProviderAvailableErrorMessage  = "none of the price providers can be built: %s"
+)
+
+// PriceProvider represents a datasource able to retrieve the electricity prices.
+// Any new datasource must implement this interface to be used by the scheduling logic
+type PriceProvider interface {
+
+	// GetPrices return the prices for the slots starting in the range [start, end).
+	// ErrPricesIncomplete is returned when the datasource says they are not published yet, so they are polled
+	GetPrices(start time.Time, end time.Time) (SlotList, error)
+
+	// Location return the timezone used by the datasource to express the prices
+	Location() *time.Location
+
+	// Resolution return the nominal time covered by each price returned by the datasource.
+	// Each slot carries its own duration, as some datasources mix several resolutions
+	Resolution() time.Duration
+}
+
+// NewPriceProvider return the price provider selected on config.
+// When 'price.providers' is defined, all of them are tried in order until one of them returns the prices.
+// Those that can not be built, like the ones not supporting the zone, are skipped from the chain.
+// When not, the one on 'price.provider' is used, and ApagaLuz is selected by default when the field is empty.
+// Every provider is wrapped by a cache when 'price.cache.directory' is defined, and the final prices
+// are calculated when 'price.composition' is enabled
+func NewPriceProvider(ctx *v1alpha1.Context) (provider PriceProvider, err error) {
+
+	providerNames := GetProviderNames(ctx)
+
+	var providers []PriceProvider
+	var names []string
+	var providerErrors []string
+
+	for _, providerName := range providerNames {
+		namedProvider, err := NewNamedPriceProvider(ctx, providerName)
+		if err != nil && len(providerNames) == 1 {
+			return provider, err
+		}
+
+		if err != nil {
+			ctx.Logger.Warnf(ProviderSkippedMessage, providerName, err)
+			providerErrors = append(providerErrors, fmt.Sprintf("%s: %s", providerName, err))
+			continue
+		}
+
+		if ctx.Config.Spec.Price.Cache.Directory != "" {
+			namedProvider = NewCachedPriceProvider(ctx, providerName, namedProvider)
+		}
+
+		providers = append(providers, namedProvider)
+		names = append(names, providerName)
+	}
+
+	if len(providers) == 0 {
+		return provider, errors.New(fmt.Sprintf(NoProviderAvailableErrorMessage, strings.Join(providerErrors, "; ")))
+	}
+
+	provider = providers[0]
+	if len(providers) > 1 {
+		provider = NewFallbackPriceProvider(ctx, names, providers)
+	}
+
+	// Composition is applied at the end, so raw prices are the ones cached
+	if ctx.Config.Spec.Price.Composition.Enabled {
+		return NewComposedPriceProvider(ctx, provider)
+	}
+
+	return provider, nil
+}
+
+// GetProviderNames return the names of the providers selected on config, in the order they are tried.
+// Those on 'price.providers' are used when defined, or the one on 'price.provider' otherwise, being ApagaLuz
+// the default one
+func GetProviderNames(ctx *v1alpha1.Context) (names []string) {
+
+	configNames := ctx.Config.Spec.Price.Providers
+	if len(configNames) == 0 {
+		configNames = []string{ctx.Config.Spec.Price.Provider}
+	}
+
+	for _, name := range configNames {
+		if name == "" {
+			name = ProviderApagaLuz
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// NewNamedPriceProvider return the price provider identified by the given name
+func NewNamedPriceProvider(ctx *v1alpha1.Context, name string) (provider PriceProvider, err error) {
+
+	switch name {
+	case ProviderApagaLuz:
+		provider, err = NewApagaLuzProvider(ctx)
+	case ProviderEsios:
+		provider, err = NewEsiosProvider(ctx)
+	case ProviderEntsoe:
+		provider, err = NewEntsoeProvider(ctx)
+	case ProviderNordPool:
+		provider, err = NewNordPoolProvider(ctx)
+	case ProviderTibber:
+		provider, err = NewTibberProvider(ctx)
+	case ProviderAwattar:
+		provider, err = NewAwattarProvider(ctx)
+	case ProviderOctopus:
+		provider, err = NewOctopusProvider(ctx)
+	case ProviderTou:
+		provider, err = NewTouProvider(ctx)
+	case ProviderFile:
+		provider, err = NewFileProvider(ctx)
+	case ProviderGeneric:
+		provider, err = NewGenericHttpProvider(ctx)
+	default:
+		err = errors.New(fmt.Sprintf(ProviderNotSupportedErrorMessage, name))
+	}
+
+	return provider, err
+}
+
+// GetProviderZone return the zone defined for the given provider, or the one on 'price.zone' when it's empty.
+// It allows chaining providers that name the same zone in a different way
+func GetProviderZone(ctx *v1alpha1.Context, name string) string {
+
+	priceConfig := ctx.Config.Spec.Price
+	zone := ""
+
+	switch name {
+	case ProviderApagaLuz:
+		zone = priceConfig.ApagaLuz.Zone
+	case ProviderEsios:
+		zone = priceConfig.Esios.Zone
+	case ProviderEntsoe:
+		zone = priceConfig.Entsoe.Zone
+	case ProviderNordPool:
+		zone = priceConfig.NordPool.Zone
+	case ProviderAwattar:
+		zone = priceConfig.Awattar.Zone
+	case ProviderOctopus:
+		zone = priceConfig.Octopus.Zone
+	case ProviderTou:
+		zone = priceConfig.Tou.Zone
+	case ProviderFile:
+		zone = priceConfig.File.Zone
+	case ProviderGeneric:
+		zone = priceConfig.GenericHttp.Zone
+	}
+
+	if zone == "" {
+		zone = priceConfig.Zone
+	}
+
+	return zone
+}
+
+// newSlotsFromInstants return the prices as slots starting at the given instants, in the given location.
+// It is used by the datasources that only publish the start of each price. All the slots last the minimum
+// time between two consecutive instants, or the default duration when there is only one
+func newSlotsFromInstants(instants []time.Time, prices []float64, location *time.Location, zone string,
+	defaultDuration time.Duration) (result SlotList) {
+
+	for index, instant := range instants {
+		result = append(result, NewSlot(instant.In(location), defaultDuration, prices[index], zone))
+	}
+
+	SortByTime(result)
+
+	slotDuration := time.Duration(0)
+	for index := 1; index < len(result); index++ {
+		gap := result[index].Start.Sub(result[index-1].Start)
+		if gap > 0 && (slotDuration == 0 || gap < slotDuration) {
+			slotDuration = gap
+		}
+	}
+
+	if slotDuration > 0 {
+		for index := range result {
+			result[index].Duration = slotDuration
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,206 @@
+// ATTENTION:
+// Tibber exposes the prices its customers pay through a GraphQL API. Prices for tomorrow are only available
+// once the day-ahead market is closed, commonly after 13:00 CET. A personal access token is required
+// [Tibber] Ref: https://developer.tibber.com/docs/overview
+
+package price
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	TibberAPIUrl = "https://api.tibber.com/v1-beta/gql"
+
+	//
+	TibberPriceInfoQuery = `{ viewer { homes { id currentSubscription { priceInfo(resolution: %s) { ` +
+		`today { total startsAt currency } tomorrow { total startsAt currency } } } } } }`
+
+	// Possible values for 'price.tibber.resolution'
+	TibberResolutionHourly        = "hourly"
+	TibberResolutionQuarterHourly = "quarterHourly"
+
+	//
+	TibberTokenNotFoundErrorMessage          = "config.price.tibber.token field is required to use Tibber provider"
+	TibberHomeNotFoundErrorMessage           = "home '%s' not found on Tibber account"
+	TibberHttpRequestFailedErrorMessage      = "error performing http request to Tibber: %s"
+	TibberHttpResponseDecodingErrorMessage   = "error decoding Tibber response: %s"
+	TibberQueryFailedErrorMessage            = "Tibber query failed: %s"
+	TibberResolutionNotSupportedErrorMessage = "config.price.tibber.resolution field must be one of: hourly, quarterHourly"
+)
+
+// TibberPriceSpec represents the price of a slot of time in Tibber API
+type TibberPriceSpec struct {
+	Total    float64 `json:"total"`
+	StartsAt string  `json:"startsAt"`
+	Currency string  `json:"currency"`
+}
+
+// TibberResponseSpec represents the fields returned by Tibber API for the price info query
+type TibberResponseSpec struct {
+	Data struct {
+		Viewer struct {
+			Homes []struct {
+				Id                  string `json:"id"`
+				CurrentSubscription struct {
+					PriceInfo struct {
+						Today    []TibberPriceSpec `json:"today"`
+						Tomorrow []TibberPriceSpec `json:"tomorrow"`
+					} `json:"priceInfo"`
+				} `json:"currentSubscription"`
+			} `json:"homes"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// TibberProvider represents a price provider that retrieves the prices of a Tibber home
+type TibberProvider struct {
+	url        string
+	httpClient *http.Client
+	token      string
+	homeId     string
+	location   *time.Location
+
+	// Resolution requested to Tibber, as named on its API, and the time it covers
+	resolution         string
+	resolutionDuration time.Duration
+}
+
+// NewTibberProvider return a Tibber provider configured for the home defined on 'price.tibber'.
+// When no home is defined, the first one in the account is used
+func NewTibberProvider(ctx *v1alpha1.Context) (provider *TibberProvider, err error) {
+
+	tibberConfig := ctx.Config.Spec.Price.Tibber
+
+	if tibberConfig.Token == "" {
+		return provider, errors.New(TibberTokenNotFoundErrorMessage)
+	}
+
+	provider = &TibberProvider{
+		httpClient: httpx.GetClient(ctx),
+		url:        TibberAPIUrl,
+		token:      tibberConfig.Token,
+		homeId:     tibberConfig.HomeId,
+		location:   time.Local,
+	}
+
+	switch tibberConfig.Resolution {
+	case "", TibberResolutionHourly:
+		provider.resolution = "HOURLY"
+		provider.resolutionDuration = time.Hour
+	case TibberResolutionQuarterHourly:
+		provider.resolution = "QUARTER_HOURLY"
+		provider.resolutionDuration = 15 * time.Minute
+	default:
+		return provider, errors.New(TibberResolutionNotSupportedErrorMessage)
+	}
+
+	if tibberConfig.URL != "" {
+		provider.url = tibberConfig.URL
+	}
+
+	// Tibber homes are located in several countries, so the timezone can be defined on config
+	if tibberConfig.Timezone != "" {
+		provider.location, err = time.LoadLocation(tibberConfig.Timezone)
+	}
+
+	return provider, err
+}
+
+// Location return the timezone of the configured home
+func (p *TibberProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the nominal time covered by each price requested to Tibber
+func (p *TibberProvider) Resolution() time.Duration {
+	return p.resolutionDuration
+}
+
+// GetPrices return the prices for the slots starting in the range [start, end).
+// Tibber only publishes the prices for today and tomorrow, so slots from other days are never returned.
+// Duration of the slots is taken from the gap between the prices returned, as it's the one really applied
+func (p *TibberProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	requestBody, err := json.Marshal(map[string]string{"query": fmt.Sprintf(TibberPriceInfoQuery, p.resolution)})
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(TibberHttpRequestFailedErrorMessage, err))
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, p.url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(TibberHttpRequestFailedErrorMessage, err))
+	}
+
+	httpRequest.Header.Set("Authorization", "Bearer "+p.token)
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(TibberHttpRequestFailedErrorMessage, err))
+	}
+
+	// Decode response's JSON into a struct
+	response := TibberResponseSpec{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return prices, errors.New(fmt.Sprintf(TibberHttpResponseDecodingErrorMessage, err))
+	}
+
+	// GraphQL errors are returned with a successful status code
+	if len(response.Errors) > 0 {
+		var messages []string
+		for _, responseError := range response.Errors {
+			messages = append(messages, responseError.Message)
+		}
+		return prices, errors.New(fmt.Sprintf(TibberQueryFailedErrorMessage, strings.Join(messages, ", ")))
+	}
+
+	homeFound := false
+	for _, home := range response.Data.Viewer.Homes {
+		if p.homeId != "" && home.Id != p.homeId {
+			continue
+		}
+		homeFound = true
+
+		var instants []time.Time
+		var instantPrices []float64
+
+		priceInfo := home.CurrentSubscription.PriceInfo
+		for _, item := range append(priceInfo.Today, priceInfo.Tomorrow...) {
+			itemTime, err := time.Parse(time.RFC3339, item.StartsAt)
+			if err != nil {
+				return prices, errors.New(fmt.Sprintf(TibberHttpResponseDecodingErrorMessage, err))
+			}
+
+			if itemTime.Before(start) || !itemTime.Before(end) {
+				continue
+			}
+
+			// Tibber express the total prices, taxes included, in currency/kWh
+			instants = append(instants, itemTime)
+			instantPrices = append(instantPrices, item.Total)
+		}
+
+		prices = newSlotsFromInstants(instants, instantPrices, p.location, home.Id, p.resolutionDuration)
+		break
+	}
+
+	if !homeFound {
+		return prices, errors.New(fmt.Sprintf(TibberHomeNotFoundErrorMessage, p.homeId))
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,241 @@
+package price
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTibberServer return a fake Tibber server publishing the prices of the given days for each one of the homes.
+// Prices of each home are its index on the account, so the selected home can be identified.
+// Queries not asking for the given price info are rejected
+func newTibberServer(t *testing.T, homes []string, today time.Time, tomorrow time.Time, slotDuration time.Duration,
+	priceInfo string) *httptest.Server {
+
+	getDayPrices := func(day time.Time, home int) (result []TibberPriceSpec) {
+		for instant := day; instant.Before(day.AddDate(0, 0, 1)); instant = instant.Add(slotDuration) {
+			result = append(result, TibberPriceSpec{
+				Total:    float64(home),
+				StartsAt: instant.Format("2006-01-02T15:04:05.000-07:00"),
+				Currency: "EUR",
+			})
+		}
+		return result
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			_, _ = w.Write([]byte(`{"errors": [{"message": "invalid token"}]}`))
+			return
+		}
+
+		request := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || !strings.Contains(request["query"], priceInfo) {
+			_, _ = w.Write([]byte(`{"errors": [{"message": "unexpected query"}]}`))
+			return
+		}
+
+		response := TibberResponseSpec{}
+		for index, home := range homes {
+			response.Data.Viewer.Homes = append(response.Data.Viewer.Homes, struct {
+				Id                  string `json:"id"`
+				CurrentSubscription struct {
+					PriceInfo struct {
+						Today    []TibberPriceSpec `json:"today"`
+						Tomorrow []TibberPriceSpec `json:"tomorrow"`
+					} `json:"priceInfo"`
+				} `json:"currentSubscription"`
+			}{Id: home})
+
+			priceInfo := &response.Data.Viewer.Homes[index].CurrentSubscription.PriceInfo
+			priceInfo.Today = getDayPrices(today, index)
+			priceInfo.Tomorrow = getDayPrices(tomorrow, index)
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTibberPrices(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+	server := newTibberServer(t, []string{"first-home", "second-home"}, start, end, time.Hour,
+		"priceInfo(resolution: HOURLY)")
+
+	tests := map[string]struct {
+		token  string
+		homeId string
+
+		// Range of the prices requested
+		start time.Time
+		end   time.Time
+
+		expectedPrice float64
+
+		// The query may be rejected, or the home may not exist
+		expectError bool
+	}{
+		"first home of the account by default": {
+			token: "token",
+			start: start,
+			end:   end,
+		},
+		"configured home": {
+			token:         "token",
+			homeId:        "second-home",
+			start:         end,
+			end:           end.AddDate(0, 0, 1),
+			expectedPrice: 1,
+		},
+		"range crossing today and tomorrow": {
+			token: "token",
+			start: start.Add(12 * time.Hour),
+			end:   end.Add(12 * time.Hour),
+		},
+		"home not found": {
+			token:       "token",
+			homeId:      "third-home",
+			start:       start,
+			end:         end,
+			expectError: true,
+		},
+		"query failed": {
+			token:       "invalid",
+			start:       start,
+			end:         end,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Tibber.URL = server.URL
+			ctx.Config.Spec.Price.Tibber.Token = test.token
+			ctx.Config.Spec.Price.Tibber.HomeId = test.homeId
+			ctx.Config.Spec.Price.Tibber.Timezone = ApagaLuzApiTimeLocation
+
+			provider, err := NewTibberProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(test.start, test.end)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the prices %v", prices)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, test.start, test.end)
+
+			for _, item := range prices {
+				if item.Price != test.expectedPrice || item.Start.Location() != provider.Location() {
+					t.Errorf("expected the price %g in %s, got %g in %s", test.expectedPrice, provider.Location(),
+						item.Price, item.Start.Location())
+				}
+			}
+		})
+	}
+}
+
+func TestTibberTokenNotFound(t *testing.T) {
+
+	ctx := newDstContext(time.Now())
+
+	if _, err := NewTibberProvider(ctx); err == nil {
+		t.Fatalf("expected the error '%s', got none", TibberTokenNotFoundErrorMessage)
+	}
+}
+
+func TestTibberResolution(t *testing.T) {
+
+	start, end := getDstDay(t, 2026, time.January, 12)
+
+	tests := map[string]struct {
+		resolution   string
+		slotDuration time.Duration
+
+		expectedQuery    string
+		expectedDuration time.Duration
+
+		// The resolution may not be supported
+		expectError bool
+	}{
+		"hourly by default": {
+			slotDuration:     time.Hour,
+			expectedQuery:    "priceInfo(resolution: HOURLY)",
+			expectedDuration: time.Hour,
+		},
+		"quarter-hourly": {
+			resolution:       TibberResolutionQuarterHourly,
+			slotDuration:     15 * time.Minute,
+			expectedQuery:    "priceInfo(resolution: QUARTER_HOURLY)",
+			expectedDuration: 15 * time.Minute,
+		},
+		"quarter-hourly prices returned for an hourly request": {
+			resolution:       TibberResolutionHourly,
+			slotDuration:     15 * time.Minute,
+			expectedQuery:    "priceInfo(resolution: HOURLY)",
+			expectedDuration: 15 * time.Minute,
+		},
+		"unsupported resolution": {
+			resolution:  "daily",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newTibberServer(t, []string{"home"}, start, end, test.slotDuration, test.expectedQuery)
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Tibber.URL = server.URL
+			ctx.Config.Spec.Price.Tibber.Token = "token"
+			ctx.Config.Spec.Price.Tibber.Timezone = ApagaLuzApiTimeLocation
+			ctx.Config.Spec.Price.Tibber.Resolution = test.resolution
+
+			provider, err := NewTibberProvider(ctx)
+			if test.expectError {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expectedSlots := int(end.Sub(start) / test.expectedDuration); len(prices) != expectedSlots {
+				t.Fatalf("expected %d slots, got %d", expectedSlots, len(prices))
+			}
+
+			for _, item := range prices {
+				if item.Duration != test.expectedDuration {
+					t.Errorf("expected slots lasting %s, got %s", test.expectedDuration, item.Duration)
+				}
+			}
+
+			if !CoversRange(prices, start, end) {
+				t.Errorf("expected prices covering from %s to %s", start, end)
+			}
+		})
+	}
+}
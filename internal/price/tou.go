@@ -0,0 +1,192 @@
+// ATTENTION:
+// Regulated 2.0TD tariffs split the day in three periods: punta (P1), llano (P2) and valle (P3).
+// Weekends, January 6 and the national holidays with fixed date that can not be substituted by the regions
+// are valle the whole day. Movable and substitutable holidays are not included, so they must be configured
+// [BOE] Ref: https://www.boe.es/buscar/act.php?id=BOE-A-2020-1066
+
+package price
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Periods defined by 2.0TD tariffs
+	TouPeriodPunta = "punta"
+	TouPeriodLlano = "llano"
+	TouPeriodValle = "valle"
+
+	//
+	touHolidayLayout = "2006-01-02"
+
+	//
+	TouPricesNotFoundErrorMessage   = "config.price.tou.fixed or config.price.tou.periods fields are required to use tou provider"
+	TouZoneNotSupportedErrorMessage = "zone '%s' is not supported by tou provider"
+	TouHolidayParsingErrorMessage   = "holidays fields must contain dates with the format YYYY-MM-DD: %s"
+)
+
+// touNationalHolidays represents the holidays that are valle on 2.0TD tariffs, as month and day.
+// January 6 is substitutable by the regions, but the tariff considers it valle everywhere
+var touNationalHolidays = [][2]int{
+	{1, 1}, {1, 6}, {5, 1}, {8, 15}, {10, 12}, {11, 1}, {12, 6}, {12, 8}, {12, 25},
+}
+
+// touWorkingDayPeriods represents the period of each hour on working days for mainland, Balearic and Canary islands.
+// Hours are expressed in the local time of each zone
+var touWorkingDayPeriods = [24]string{
+	TouPeriodValle, TouPeriodValle, TouPeriodValle, TouPeriodValle, TouPeriodValle, TouPeriodValle, // 00-06
+	TouPeriodValle, TouPeriodValle, TouPeriodLlano, TouPeriodLlano, TouPeriodPunta, TouPeriodPunta, // 06-12
+	TouPeriodPunta, TouPeriodPunta, TouPeriodLlano, TouPeriodLlano, TouPeriodLlano, TouPeriodLlano, // 12-18
+	TouPeriodPunta, TouPeriodPunta, TouPeriodPunta, TouPeriodPunta, TouPeriodLlano, TouPeriodLlano, // 18-24
+}
+
+// touCeutaMelillaWorkingDayPeriods represents the period of each hour on working days for Ceuta and Melilla
+var touCeutaMelillaWorkingDayPeriods = [24]string{
+	TouPeriodValle, TouPeriodValle, TouPeriodValle, TouPeriodValle, TouPeriodValle, TouPeriodValle, // 00-06
+	TouPeriodValle, TouPeriodValle, TouPeriodLlano, TouPeriodLlano, TouPeriodLlano, TouPeriodPunta, // 06-12
+	TouPeriodPunta, TouPeriodPunta, TouPeriodPunta, TouPeriodLlano, TouPeriodLlano, TouPeriodLlano, // 12-18
+	TouPeriodLlano, TouPeriodPunta, TouPeriodPunta, TouPeriodPunta, TouPeriodPunta, TouPeriodLlano, // 18-24
+}
+
+// TouProvider represents a price provider that builds the prices from the periods of a time-of-use tariff,
+// or from a fixed price for every hour
+type TouProvider struct {
+	zone     string
+	fixed    *float64
+	prices   map[string]float64
+	periods  [24]string
+	holidays map[string]bool
+	location *time.Location
+}
+
+// NewTouProvider return a time-of-use provider configured as defined on 'price.tou'
+// for the zone on 'price.tou.zone', or on 'price.zone' when the provider does not define its own
+func NewTouProvider(ctx *v1alpha1.Context) (provider *TouProvider, err error) {
+
+	touConfig := ctx.Config.Spec.Price.Tou
+
+	if touConfig.Fixed == nil && touConfig.Periods == (v1alpha1.TouPeriodsSpec{}) {
+		return provider, errors.New(TouPricesNotFoundErrorMessage)
+	}
+
+	zone := GetProviderZone(ctx, ProviderTou)
+	if zone == "" {
+		zone = "mainland"
+	}
+
+	provider, err = newTouCalendar(zone, touConfig.Holidays)
+	if err != nil {
+		return provider, err
+	}
+
+	provider.fixed = touConfig.Fixed
+	provider.prices = map[string]float64{
+		TouPeriodPunta: touConfig.Periods.Punta,
+		TouPeriodLlano: touConfig.Periods.Llano,
+		TouPeriodValle: touConfig.Periods.Valle,
+	}
+
+	return provider, nil
+}
+
+// newTouCalendar return a provider only able to know the periods of the given zone, without prices.
+// Given holidays are added to the national ones
+func newTouCalendar(zone string, holidays []string) (provider *TouProvider, err error) {
+
+	provider = &TouProvider{
+		zone:     zone,
+		periods:  touWorkingDayPeriods,
+		holidays: map[string]bool{},
+	}
+
+	apiTimeLocation := "Europe/Madrid"
+	switch zone {
+	case "mainland", "balearicislands":
+	case "canaryislands":
+		apiTimeLocation = "Atlantic/Canary"
+	case "ceuta", "melilla":
+		provider.periods = touCeutaMelillaWorkingDayPeriods
+	default:
+		return provider, errors.New(fmt.Sprintf(TouZoneNotSupportedErrorMessage, zone))
+	}
+
+	for _, holiday := range holidays {
+		holidayTime, err := time.Parse(touHolidayLayout, holiday)
+		if err != nil {
+			return provider, errors.New(fmt.Sprintf(TouHolidayParsingErrorMessage, err))
+		}
+		provider.holidays[holidayTime.Format(touHolidayLayout)] = true
+	}
+
+	provider.location, err = time.LoadLocation(apiTimeLocation)
+	return provider, err
+}
+
+// Location return the timezone used to express the periods of the configured zone
+func (p *TouProvider) Location() *time.Location {
+	return p.location
+}
+
+// Resolution return the time covered by each price returned by the provider
+func (p *TouProvider) Resolution() time.Duration {
+	return time.Hour
+}
+
+// GetPrices return the prices for the hours starting in the range [start, end).
+// They are always known, as they come from the tariff itself
+func (p *TouProvider) GetPrices(start time.Time, end time.Time) (prices SlotList, err error) {
+
+	// Hours are aligned to the beginning of each hour in the zone
+	hourTime := start.In(p.location).Truncate(time.Hour)
+	if hourTime.Before(start) {
+		hourTime = hourTime.Add(time.Hour)
+	}
+
+	for ; hourTime.Before(end); hourTime = hourTime.Add(time.Hour) {
+		prices = append(prices, NewSlot(hourTime, time.Hour, p.getPrice(hourTime), p.zone))
+	}
+
+	return prices, nil
+}
+
+// GetPeriod return the period of the tariff for the hour starting at the given moment
+func (p *TouProvider) GetPeriod(hourTime time.Time) string {
+
+	hourTime = hourTime.In(p.location)
+
+	if hourTime.Weekday() == time.Saturday || hourTime.Weekday() == time.Sunday || p.isHoliday(hourTime) {
+		return TouPeriodValle
+	}
+
+	return p.periods[hourTime.Hour()]
+}
+
+// getPrice return the price for the hour starting at the given moment
+func (p *TouProvider) getPrice(hourTime time.Time) float64 {
+
+	if p.fixed != nil {
+		return *p.fixed
+	}
+
+	return p.prices[p.GetPeriod(hourTime)]
+}
+
+// isHoliday return true when the day of the given moment is a national holiday or one of the configured ones
+func (p *TouProvider) isHoliday(dayTime time.Time) bool {
+
+	if p.holidays[dayTime.Format(touHolidayLayout)] {
+		return true
+	}
+
+	for _, holiday := range touNationalHolidays {
+		if int(dayTime.Month()) == holiday[0] && dayTime.Day() == holiday[1] {
+			return true
+		}
+	}
+
+	return false
+}
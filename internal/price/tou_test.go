@@ -0,0 +1,193 @@
+package price
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTouPeriods(t *testing.T) {
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canary, err := time.LoadLocation("Atlantic/Canary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		zone     string
+		holidays []string
+		hourTime time.Time
+		expected string
+	}{
+		"last valle hour of a working day": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 12, 7, 0, 0, 0, madrid),
+			expected: TouPeriodValle,
+		},
+		"first llano hour of a working day": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 12, 8, 0, 0, 0, madrid),
+			expected: TouPeriodLlano,
+		},
+		"first punta hour of the morning": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 12, 10, 0, 0, 0, madrid),
+			expected: TouPeriodPunta,
+		},
+		"first llano hour of the afternoon": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 12, 14, 0, 0, 0, madrid),
+			expected: TouPeriodLlano,
+		},
+		"last punta hour of the evening": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 12, 21, 0, 0, 0, madrid),
+			expected: TouPeriodPunta,
+		},
+		"last llano hour of a working day": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 12, 23, 0, 0, 0, madrid),
+			expected: TouPeriodLlano,
+		},
+		"saturday": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 10, 11, 0, 0, 0, madrid),
+			expected: TouPeriodValle,
+		},
+		"sunday": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 11, 19, 0, 0, 0, madrid),
+			expected: TouPeriodValle,
+		},
+		"january 6 on a working day": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.January, 6, 11, 0, 0, 0, madrid),
+			expected: TouPeriodValle,
+		},
+		"national holiday on a working day": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.December, 25, 11, 0, 0, 0, madrid),
+			expected: TouPeriodValle,
+		},
+		"substitutable holiday not configured": {
+			zone:     "mainland",
+			hourTime: time.Date(2026, time.March, 19, 11, 0, 0, 0, madrid),
+			expected: TouPeriodPunta,
+		},
+		"movable holiday configured": {
+			zone:     "mainland",
+			holidays: []string{"2026-04-03"},
+			hourTime: time.Date(2026, time.April, 3, 11, 0, 0, 0, madrid),
+			expected: TouPeriodValle,
+		},
+		"ceuta shifts the punta hours": {
+			zone:     "ceuta",
+			hourTime: time.Date(2026, time.January, 12, 10, 0, 0, 0, madrid),
+			expected: TouPeriodLlano,
+		},
+		"canary islands use their local time": {
+			zone:     "canaryislands",
+			hourTime: time.Date(2026, time.January, 12, 10, 0, 0, 0, canary),
+			expected: TouPeriodPunta,
+		},
+		"moments of other timezones are moved to the canary islands": {
+			zone:     "canaryislands",
+			hourTime: time.Date(2026, time.January, 12, 22, 0, 0, 0, madrid),
+			expected: TouPeriodPunta,
+		},
+		"holiday in mainland still being the day before in the canary islands": {
+			zone:     "canaryislands",
+			hourTime: time.Date(2026, time.January, 6, 0, 0, 0, 0, madrid),
+			expected: TouPeriodLlano,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			calendar, err := newTouCalendar(test.zone, test.holidays)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if period := calendar.GetPeriod(test.hourTime); period != test.expected {
+				t.Errorf("expected the period %s at %s, got %s", test.expected, test.hourTime, period)
+			}
+		})
+	}
+}
+
+func TestTouPrices(t *testing.T) {
+
+	tests := map[string]struct {
+		month time.Month
+		day   int
+		fixed *float64
+
+		expectedPunta int
+	}{
+		"working day": {
+			month:         time.January,
+			day:           12,
+			expectedPunta: 8,
+		},
+		"spring forward on a sunday": {
+			month: time.March,
+			day:   29,
+		},
+		"fall back on a sunday": {
+			month: time.October,
+			day:   25,
+		},
+		"fixed price": {
+			month: time.January,
+			day:   12,
+			fixed: new(float64),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end := getDstDay(t, 2026, test.month, test.day)
+
+			ctx := newDstContext(start)
+			ctx.Config.Spec.Price.Tou.Fixed = test.fixed
+			ctx.Config.Spec.Price.Tou.Periods.Punta = 0.24
+			ctx.Config.Spec.Price.Tou.Periods.Llano = 0.16
+			ctx.Config.Spec.Price.Tou.Periods.Valle = 0.09
+
+			provider, err := NewTouProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prices, err := provider.GetPrices(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertConsecutiveHours(t, prices, start, end)
+
+			punta := 0
+			for _, item := range prices {
+				if item.Price == 0.24 {
+					punta++
+				}
+			}
+
+			if punta != test.expectedPunta {
+				t.Errorf("expected %d punta hours, got %d", test.expectedPunta, punta)
+			}
+		})
+	}
+}
+
+func TestTouZoneNotSupported(t *testing.T) {
+
+	if _, err := newTouCalendar("portugal", nil); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
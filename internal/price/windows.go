@@ -0,0 +1,237 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Possible values for 'device.windows[].type'
+	WindowTypeAllow   = "allow"
+	WindowTypeDeny    = "deny"
+	WindowTypeRequire = "require"
+
+	//
+	windowTimeLayout = "15:04"
+
+	//
+	WindowTypeNotSupportedErrorMessage    = "config.device.windows[%d].type field must be one of: allow, deny, require"
+	WindowTimeParsingErrorMessage         = "config.device.windows[%d] start and end fields must be times with the format HH:MM: %s"
+	WindowDayNotSupportedErrorMessage     = "config.device.windows[%d].days field contains an unknown day: %s"
+	WindowMinDurationParsingErrorMessage  = "config.device.windows[%d].minDuration field must be a duration like 2h: %s"
+	WindowMinDurationNotFoundErrorMessage = "config.device.windows[%d].minDuration field is required for 'require' windows"
+)
+
+// windowDays represents the relation between the names accepted on 'device.windows[].days' and the weekdays
+var windowDays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday,
+}
+
+// Window represents a daily range of time where the device is allowed, forbidden or required to be turned on.
+// Ranges whose end is before their start finish the following day
+type Window struct {
+	Type string
+
+	// Weekdays when the range starts. Empty means every day
+	Days map[time.Weekday]bool
+
+	// Offsets from midnight in local time
+	Start time.Duration
+	End   time.Duration
+
+	// Minimum time the device must be turned on inside the range, for 'require' windows
+	MinDuration time.Duration
+}
+
+// windowOccurrence represents a specific day of a window
+type windowOccurrence struct {
+	window int
+	start  time.Time
+}
+
+// GetWindows return the windows defined on 'device.windows'
+func GetWindows(ctx *v1alpha1.Context) (windows []Window, err error) {
+
+	for index, windowConfig := range ctx.Config.Spec.Device.Windows {
+
+		window := Window{Type: windowConfig.Type}
+
+		switch window.Type {
+		case WindowTypeAllow, WindowTypeDeny, WindowTypeRequire:
+		default:
+			return windows, errors.New(fmt.Sprintf(WindowTypeNotSupportedErrorMessage, index))
+		}
+
+		windowStart, err := time.Parse(windowTimeLayout, windowConfig.Start)
+		if err != nil {
+			return windows, errors.New(fmt.Sprintf(WindowTimeParsingErrorMessage, index, err))
+		}
+
+		windowEnd, err := time.Parse(windowTimeLayout, windowConfig.End)
+		if err != nil {
+			return windows, errors.New(fmt.Sprintf(WindowTimeParsingErrorMessage, index, err))
+		}
+
+		window.Start = time.Duration(windowStart.Hour())*time.Hour + time.Duration(windowStart.Minute())*time.Minute
+		window.End = time.Duration(windowEnd.Hour())*time.Hour + time.Duration(windowEnd.Minute())*time.Minute
+
+		if len(windowConfig.Days) > 0 {
+			window.Days = map[time.Weekday]bool{}
+		}
+
+		for _, day := range windowConfig.Days {
+			weekday, dayFound := windowDays[strings.ToLower(day)]
+			if !dayFound {
+				return windows, errors.New(fmt.Sprintf(WindowDayNotSupportedErrorMessage, index, day))
+			}
+			window.Days[weekday] = true
+		}
+
+		if window.Type == WindowTypeRequire {
+			if windowConfig.MinDuration == "" {
+				return windows, errors.New(fmt.Sprintf(WindowMinDurationNotFoundErrorMessage, index))
+			}
+
+			window.MinDuration, err = time.ParseDuration(windowConfig.MinDuration)
+			if err != nil {
+				return windows, errors.New(fmt.Sprintf(WindowMinDurationParsingErrorMessage, index, err))
+			}
+		}
+
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// occurrence return the start of the occurrence of the window containing the given moment, if any
+func (w Window) occurrence(moment time.Time) (start time.Time, found bool) {
+
+	moment = moment.In(time.Local)
+
+	// Occurrences started yesterday can still be running when crossing midnight
+	for _, dayOffset := range []int{0, -1} {
+		day := time.Date(moment.Year(), moment.Month(), moment.Day()+dayOffset, 0, 0, 0, 0, time.Local)
+
+		if w.Days != nil && !w.Days[day.Weekday()] {
+			continue
+		}
+
+		// Wall clock is used, so DST changes are handled as the user expects
+		occurrenceStart := time.Date(day.Year(), day.Month(), day.Day(), 0, int(w.Start/time.Minute), 0, 0, time.Local)
+		occurrenceEnd := time.Date(day.Year(), day.Month(), day.Day(), 0, int(w.End/time.Minute), 0, 0, time.Local)
+		if !occurrenceEnd.After(occurrenceStart) {
+			occurrenceEnd = time.Date(day.Year(), day.Month(), day.Day()+1, 0, int(w.End/time.Minute), 0, 0, time.Local)
+		}
+
+		if !moment.Before(occurrenceStart) && moment.Before(occurrenceEnd) {
+			return occurrenceStart, true
+		}
+	}
+
+	return start, false
+}
+
+// slotRules represents how the windows affect each one of the slots
+type slotRules struct {
+
+	// Whether each slot can be selected
+	allowed []bool
+
+	// Whether each slot must be selected
+	forced []bool
+
+	// Occurrence of 'require' windows each slot belongs to, as index of 'requirements'. -1 means none
+	requirement []int
+
+	// Minimum time to select inside each occurrence of 'require' windows
+	requirements []time.Duration
+}
+
+// getSlotRules return the rules that the windows and the price thresholds impose to the given prices, sorted by time.
+// Requirements are limited to the time covered by the prices, as passed slots can not be selected anymore.
+// Overlapping 'require' windows are not supported, so slots only count for the first one
+func getSlotRules(prices SlotList, constraints Constraints) (rules slotRules) {
+
+	windows := constraints.Windows
+
+	rules.allowed = make([]bool, len(prices))
+	rules.forced = make([]bool, len(prices))
+	rules.requirement = make([]int, len(prices))
+	requirementIndexes := map[windowOccurrence]int{}
+
+	hasAllowWindows := false
+	for _, window := range windows {
+		if window.Type == WindowTypeAllow {
+			hasAllowWindows = true
+		}
+	}
+
+	for index, item := range prices {
+		insideAllowWindow := false
+		insideDenyWindow := false
+		rules.requirement[index] = -1
+
+		for windowIndex, window := range windows {
+
+			// Slots must be completely inside or outside the windows, so both ends are checked
+			_, startInside := window.occurrence(item.Start)
+			_, endInside := window.occurrence(item.End().Add(-time.Nanosecond))
+
+			switch window.Type {
+			case WindowTypeAllow:
+				insideAllowWindow = insideAllowWindow || (startInside && endInside)
+			case WindowTypeDeny:
+				insideDenyWindow = insideDenyWindow || startInside || endInside
+			case WindowTypeRequire:
+				occurrenceStart, found := window.occurrence(item.Start)
+				if !found || rules.requirement[index] != -1 {
+					continue
+				}
+
+				occurrence := windowOccurrence{window: windowIndex, start: occurrenceStart}
+				requirementIndex, requirementFound := requirementIndexes[occurrence]
+				if !requirementFound {
+					requirementIndex = len(rules.requirements)
+					requirementIndexes[occurrence] = requirementIndex
+					rules.requirements = append(rules.requirements, 0)
+				}
+
+				rules.requirement[index] = requirementIndex
+			}
+		}
+
+		// Forbidden ranges and the maximum price have priority over the rest
+		rules.allowed[index] = (!hasAllowWindows || insideAllowWindow) && !insideDenyWindow
+		if constraints.MaxPrice != nil && item.PriceFor(constraints.ThresholdsBasis) > *constraints.MaxPrice {
+			rules.allowed[index] = false
+		}
+
+		rules.forced[index] = rules.allowed[index] &&
+			constraints.AlwaysRunBelow != nil && item.PriceFor(constraints.ThresholdsBasis) < *constraints.AlwaysRunBelow
+	}
+
+	// Requirements can not exceed the time that can be selected inside their occurrences
+	available := make([]time.Duration, len(rules.requirements))
+	for index, item := range prices {
+		if rules.requirement[index] != -1 && rules.allowed[index] {
+			available[rules.requirement[index]] += item.Duration
+		}
+	}
+
+	for occurrence, requirementIndex := range requirementIndexes {
+		rules.requirements[requirementIndex] = minDuration(windows[occurrence.window].MinDuration, available[requirementIndex])
+	}
+
+	return rules
+}
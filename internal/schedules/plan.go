@@ -0,0 +1,194 @@
+package schedules
+
+import (
+	"sync"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/price"
+	"github.com/achetronic/autoheater/internal/state"
+)
+
+const (
+	// Name of the document storing the plan of the current window in the state directory
+	PlanStateName = "plan"
+
+	//
+	PlanResumedMessage          = "resuming the plan stored for this window. run time: %s, owed time: %s"
+	PlanToppedUpMessage         = "plan topped up with %d schedules to cover the owed time"
+	PlanReadFailedMessage       = "impossible to read the stored plan, crafting a new one: %s"
+	PlanWriteFailedMessage      = "impossible to store the plan: %s"
+	PlanTopUpFailedErrorMessage = "impossible to top up the stored plan: %s"
+)
+
+// PlanState represents the plan crafted for a planning window, and what has been executed from it.
+// It's stored on disk, so a restarted process resumes the same plan instead of crafting a new one
+type PlanState struct {
+	WindowStart    time.Time        `json:"windowStart"`
+	WindowEnd      time.Time        `json:"windowEnd"`
+	ActiveDuration time.Duration    `json:"activeDuration"`
+	Schedules      []price.Schedule `json:"schedules"`
+
+	// Transitions already executed, at the moment they were really executed
+	Executed []Transition `json:"executed"`
+}
+
+// PlanStore represents the plan of the current window and what has been executed from it.
+// It's kept in memory, and stored on disk once per change, as transitions are recorded from several goroutines
+type PlanStore struct {
+	mutex sync.Mutex
+
+	// Plan being executed. Nil until one is loaded or saved
+	plan *PlanState
+}
+
+// NewPlanStore return an empty plan store. The stored plan is read from disk by Load
+func NewPlanStore() *PlanStore {
+	return &PlanStore{}
+}
+
+// Load return the plan stored on disk for the given window, if any. It's kept in memory from then on
+func (s *PlanStore) Load(ctx *v1alpha1.Context, window PlanningWindow) (plan PlanState, found bool) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	found, err := state.Load(ctx, PlanStateName, &plan)
+	if err != nil {
+		ctx.Logger.Infof(PlanReadFailedMessage, err)
+		return plan, false
+	}
+
+	if !found || !plan.WindowStart.Equal(window.Start) || !plan.WindowEnd.Equal(window.End) {
+		return plan, false
+	}
+
+	s.plan = &plan
+	return plan, true
+}
+
+// Save replace the plan being executed with the given one, and store it
+func (s *PlanStore) Save(ctx *v1alpha1.Context, plan PlanState) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.plan = &plan
+	s.store(ctx)
+}
+
+// RecordTransition add the given transition to the executed ones of the plan, and store it.
+// Nothing is recorded until a plan is loaded or saved
+func (s *PlanStore) RecordTransition(ctx *v1alpha1.Context, transition Transition) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.plan == nil {
+		return
+	}
+
+	s.plan.Executed = append(s.plan.Executed, transition)
+	s.store(ctx)
+}
+
+// GetSchedules return the schedules of the plan being executed, or false when there is no plan yet
+func (s *PlanStore) GetSchedules() (schedules []price.Schedule, found bool) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.plan == nil {
+		return schedules, false
+	}
+
+	return append(schedules, s.plan.Schedules...), true
+}
+
+// store write the plan being executed on disk. The mutex must be held by the caller
+func (s *PlanStore) store(ctx *v1alpha1.Context) {
+
+	err := state.Save(ctx, PlanStateName, s.plan)
+	if err != nil {
+		ctx.Logger.Infof(PlanWriteFailedMessage, err)
+	}
+}
+
+// RunDuration return the time the device has been turned on by the executed transitions until the given moment.
+// When the last transition turned it on, it's considered to be still turned on
+func (p PlanState) RunDuration(currentTime time.Time) (runDuration time.Duration) {
+
+	var onSince *time.Time
+
+	for index, transition := range p.Executed {
+		if transition.TurnOn && onSince == nil {
+			onSince = &p.Executed[index].Time
+			continue
+		}
+
+		if !transition.TurnOn && onSince != nil {
+			runDuration += transition.Time.Sub(*onSince)
+			onSince = nil
+		}
+	}
+
+	if onSince != nil && currentTime.After(*onSince) {
+		runDuration += currentTime.Sub(*onSince)
+	}
+
+	return runDuration
+}
+
+// PendingDuration return the time covered by the schedules of the plan from the given moment
+func (p PlanState) PendingDuration(currentTime time.Time) (pendingDuration time.Duration) {
+
+	for _, schedule := range p.Schedules {
+		scheduleStart := schedule.Start
+		if scheduleStart.Before(currentTime) {
+			scheduleStart = currentTime
+		}
+
+		if schedule.Stop.After(scheduleStart) {
+			pendingDuration += schedule.Stop.Sub(scheduleStart)
+		}
+	}
+
+	return pendingDuration
+}
+
+// ResumePlan return the schedules of the given stored plan, topped up with new ones when the time already run
+// plus the pending one does not cover the active duration. This happens when the process was stopped in the middle
+// of a schedule. Topped up plans are saved on the given store, so it must be called before scheduling its actions
+func ResumePlan(ctx *v1alpha1.Context, plans *PlanStore, plan PlanState, currentTime time.Time) []price.Schedule {
+
+	runDuration := plan.RunDuration(currentTime)
+	owedDuration := plan.ActiveDuration - runDuration - plan.PendingDuration(currentTime)
+	if owedDuration < 0 {
+		owedDuration = 0
+	}
+
+	ctx.Logger.Infof(PlanResumedMessage, runDuration.Round(time.Second), owedDuration.Round(time.Second))
+
+	// Less than a minute is not worth turning on the device
+	if owedDuration < time.Minute {
+		return plan.Schedules
+	}
+
+	temperatures := GetCopTemperatures(ctx)
+	topUpSchedules, err := price.GetTopUpSchedules(ctx, currentTime, plan.WindowEnd, owedDuration, temperatures, plan.Schedules)
+	if err != nil {
+		ctx.Logger.Infof(PlanTopUpFailedErrorMessage, err)
+		return plan.Schedules
+	}
+
+	if len(topUpSchedules) == 0 {
+		return plan.Schedules
+	}
+
+	ctx.Logger.Infof(PlanToppedUpMessage, len(topUpSchedules))
+
+	plan.Schedules = append(plan.Schedules, topUpSchedules...)
+	plans.Save(ctx, plan)
+
+	return plan.Schedules
+}
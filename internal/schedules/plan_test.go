@@ -0,0 +1,252 @@
+package schedules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+	"github.com/achetronic/autoheater/internal/price"
+	"go.uber.org/zap"
+)
+
+// newPlanContext return a context storing the state in a temporary directory, whose prices are read
+// from a CSV file with the given hourly prices, starting at the given moment
+func newPlanContext(t *testing.T, start time.Time, prices ...float64) *v1alpha1.Context {
+
+	lines := []string{"time,price"}
+	for index, hourPrice := range prices {
+		lines = append(lines, fmt.Sprintf("%s,%g", start.Add(time.Duration(index)*time.Hour).Format(time.RFC3339), hourPrice))
+	}
+
+	pricesPath := filepath.Join(t.TempDir(), "prices.csv")
+	err := os.WriteFile(pricesPath, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &v1alpha1.Context{
+		Config:         &v1alpha1.ConfigSpec{},
+		Logger:         zap.NewNop().Sugar(),
+		StateDirectory: t.TempDir(),
+		Clock:          clocktest.NewFakeClock(start),
+	}
+
+	ctx.Config.Spec.Price.Provider = price.ProviderFile
+	ctx.Config.Spec.Price.File.Path = pricesPath
+	ctx.Config.Spec.Price.File.Mapping.Fields.Timestamp = "time"
+	ctx.Config.Spec.Price.File.Mapping.Fields.Price = "price"
+
+	return ctx
+}
+
+// getPlanSchedule return a schedule between the given offsets from the start
+func getPlanSchedule(start time.Time, from time.Duration, to time.Duration) price.Schedule {
+	return price.Schedule{Start: start.Add(from), Stop: start.Add(to)}
+}
+
+func TestPlanStoreLoad(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	window := PlanningWindow{Start: start, End: start.AddDate(0, 0, 1)}
+
+	tests := map[string]struct {
+		stored        *PlanState
+		expectedFound bool
+	}{
+		"missing state file": {},
+		"plan of a previous window": {
+			stored: &PlanState{WindowStart: start.AddDate(0, 0, -1), WindowEnd: start},
+		},
+		"plan of the window": {
+			stored:        &PlanState{WindowStart: window.Start, WindowEnd: window.End},
+			expectedFound: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newPlanContext(t, start)
+
+			if test.stored != nil {
+				plans := NewPlanStore()
+				plans.Save(ctx, *test.stored)
+				plans.RecordTransition(ctx, Transition{Time: start.Add(time.Hour), TurnOn: true})
+			}
+
+			// A new store reads the plan from disk, as a restarted process does
+			plan, found := NewPlanStore().Load(ctx, window)
+			if found != test.expectedFound {
+				t.Fatalf("expected found %t, got %t", test.expectedFound, found)
+			}
+
+			if found && len(plan.Executed) != 1 {
+				t.Errorf("expected the executed transition to be stored, got %v", plan.Executed)
+			}
+		})
+	}
+}
+
+func TestPlanStoreRecordTransition(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	window := PlanningWindow{Start: start, End: start.AddDate(0, 0, 1)}
+	ctx := newPlanContext(t, start)
+
+	// Nothing is recorded until there is a plan
+	plans := NewPlanStore()
+	plans.RecordTransition(ctx, Transition{Time: start, TurnOn: false})
+
+	if _, found := NewPlanStore().Load(ctx, window); found {
+		t.Fatal("expected no plan stored before saving one")
+	}
+
+	plans.Save(ctx, PlanState{WindowStart: window.Start, WindowEnd: window.End})
+	plans.RecordTransition(ctx, Transition{Time: start.Add(time.Hour), TurnOn: true})
+	plans.RecordTransition(ctx, Transition{Time: start.Add(2 * time.Hour), TurnOn: false})
+
+	plan, found := NewPlanStore().Load(ctx, window)
+	if !found || len(plan.Executed) != 2 {
+		t.Fatalf("expected a plan with 2 executed transitions, got %v", plan)
+	}
+}
+
+func TestPlanStateDurations(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	schedules := []price.Schedule{
+		getPlanSchedule(start, 0, time.Hour),
+		getPlanSchedule(start, 2*time.Hour, 4*time.Hour),
+	}
+
+	tests := map[string]struct {
+		executed    []Transition
+		currentTime time.Time
+		expectedRun time.Duration
+		pending     time.Duration
+	}{
+		"plan not started": {
+			currentTime: start,
+			expectedRun: 0,
+			pending:     3 * time.Hour,
+		},
+		"plan partly run and still turned on": {
+			executed: []Transition{
+				{Time: start, TurnOn: true},
+				{Time: start.Add(time.Hour), TurnOn: false},
+				{Time: start.Add(2 * time.Hour), TurnOn: true},
+			},
+			currentTime: start.Add(2*time.Hour + 30*time.Minute),
+			expectedRun: time.Hour + 30*time.Minute,
+			pending:     time.Hour + 30*time.Minute,
+		},
+		"plan stopped in the middle of a schedule": {
+			executed: []Transition{
+				{Time: start, TurnOn: true},
+				{Time: start.Add(30 * time.Minute), TurnOn: false},
+			},
+			currentTime: start.Add(time.Hour),
+			expectedRun: 30 * time.Minute,
+			pending:     2 * time.Hour,
+		},
+		"expired plan": {
+			executed: []Transition{
+				{Time: start, TurnOn: true},
+				{Time: start.Add(time.Hour), TurnOn: false},
+			},
+			currentTime: start.AddDate(0, 0, 1),
+			expectedRun: time.Hour,
+			pending:     0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			plan := PlanState{Schedules: schedules, Executed: test.executed}
+
+			if runDuration := plan.RunDuration(test.currentTime); runDuration != test.expectedRun {
+				t.Errorf("expected a run duration of %s, got %s", test.expectedRun, runDuration)
+			}
+
+			if pendingDuration := plan.PendingDuration(test.currentTime); pendingDuration != test.pending {
+				t.Errorf("expected a pending duration of %s, got %s", test.pending, pendingDuration)
+			}
+		})
+	}
+}
+
+func TestResumePlan(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	prices := []float64{0.05, 0.06, 0.30, 0.30, 0.10, 0.30}
+	window := PlanningWindow{Start: start, End: start.Add(6 * time.Hour)}
+
+	tests := map[string]struct {
+		executed    []Transition
+		currentTime time.Time
+
+		// Schedules expected after resuming, as offsets from the start
+		expected [][2]time.Duration
+	}{
+		"plan running as expected": {
+			executed:    []Transition{{Time: start, TurnOn: true}},
+			currentTime: start.Add(time.Hour),
+			expected:    [][2]time.Duration{{0, 2 * time.Hour}},
+		},
+		"plan stopped in the middle of a schedule is topped up": {
+			executed: []Transition{
+				{Time: start, TurnOn: true},
+				{Time: start.Add(30 * time.Minute), TurnOn: false},
+			},
+			currentTime: start.Add(time.Hour),
+			expected:    [][2]time.Duration{{0, 2 * time.Hour}, {4*time.Hour + 30*time.Minute, 5 * time.Hour}},
+		},
+		"expired plan is not topped up": {
+			executed:    []Transition{{Time: start, TurnOn: true}, {Time: start.Add(30 * time.Minute), TurnOn: false}},
+			currentTime: window.End.Add(time.Hour),
+			expected:    [][2]time.Duration{{0, 2 * time.Hour}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newPlanContext(t, start, prices...)
+
+			plans := NewPlanStore()
+			plans.Save(ctx, PlanState{
+				WindowStart:    window.Start,
+				WindowEnd:      window.End,
+				ActiveDuration: 2 * time.Hour,
+				Schedules:      []price.Schedule{getPlanSchedule(start, 0, 2*time.Hour)},
+				Executed:       test.executed,
+			})
+
+			plan, found := plans.Load(ctx, window)
+			if !found {
+				t.Fatal("expected the plan to be found")
+			}
+
+			schedules := ResumePlan(ctx, plans, plan, test.currentTime)
+			if len(schedules) != len(test.expected) {
+				t.Fatalf("expected %d schedules, got %d: %v", len(test.expected), len(schedules), schedules)
+			}
+
+			for index, offsets := range test.expected {
+				if !schedules[index].Start.Equal(start.Add(offsets[0])) || !schedules[index].Stop.Equal(start.Add(offsets[1])) {
+					t.Errorf("expected schedule from %s to %s, got from %s to %s", start.Add(offsets[0]),
+						start.Add(offsets[1]), schedules[index].Start, schedules[index].Stop)
+				}
+			}
+
+			// Topped up schedules are stored, so they are resumed after another restart
+			storedPlan, _ := NewPlanStore().Load(ctx, window)
+			if len(storedPlan.Schedules) != len(test.expected) {
+				t.Errorf("expected %d schedules stored, got %d", len(test.expected), len(storedPlan.Schedules))
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package schedules
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/integrations/taposmartplug"
+	"github.com/achetronic/autoheater/internal/price"
+)
+
+const (
+	// Default value for 'device.reconciliation.interval'
+	DefaultReconciliationInterval = 60 * time.Second
+
+	// Moments close to a programmed transition are not reconciled, as the action can be still running
+	ReconciliationGracePeriod = 1 * time.Minute
+
+	//
+	ReconcilerStartedMessage      = "reconciler is running every %s"
+	ReconcilerNotSupportedMessage = "no configured integration is able to read the state of the device. reconciler disabled"
+	DriftCorrectedMessage         = "device found %s while it should be %s. state corrected (%d corrections so far)"
+
+	//
+	ReconciliationIntervalParsingErrorMessage = "config.device.reconciliation.interval field must be a duration: %s"
+	TapoStateReadFailedErrorMessage           = "error reading the state for 'tapo smartplug' integration: %s"
+)
+
+// Device represents the device whose state is reconciled
+type Device interface {
+	// IsOn return whether the device is turned on
+	IsOn(ctx *v1alpha1.Context) (bool, error)
+
+	// TurnOn turn on the device
+	TurnOn(ctx *v1alpha1.Context)
+
+	// TurnOff turn off the device
+	TurnOff(ctx *v1alpha1.Context)
+}
+
+// IntegrationsDevice represents the device driven by the integrations defined on 'device.integrations'.
+// Only Tapo smart plugs are able to report their state
+type IntegrationsDevice struct{}
+
+// IsOn return whether the Tapo smart plug is turned on
+func (d IntegrationsDevice) IsOn(ctx *v1alpha1.Context) (bool, error) {
+	return taposmartplug.IsDeviceOn(ctx)
+}
+
+// TurnOn execute the 'start' action for each defined integration
+func (d IntegrationsDevice) TurnOn(ctx *v1alpha1.Context) {
+	ExecuteStartAction(ctx)
+}
+
+// TurnOff execute the 'stop' action for each defined integration
+func (d IntegrationsDevice) TurnOff(ctx *v1alpha1.Context) {
+	ExecuteStopAction(ctx)
+}
+
+// Reconciler represents the process comparing the state of the device with the one expected by the plan
+// being executed, and re-applying the expected one on drift
+type Reconciler struct {
+	device Device
+	plans  *PlanStore
+
+	// mutex protects the corrections counter
+	mutex sync.Mutex
+
+	// Number of drifts corrected since the process started
+	corrections int
+}
+
+// NewReconciler return a reconciler for the given device, expecting the plan being executed on the given store
+func NewReconciler(device Device, plans *PlanStore) *Reconciler {
+	return &Reconciler{
+		device: device,
+		plans:  plans,
+	}
+}
+
+// GetDesiredState return whether the device must be turned on at the given moment according to the given schedules.
+// When the moment is close to a transition, it's considered unstable and nothing must be done
+func GetDesiredState(schedules []price.Schedule, currentTime time.Time) (turnOn bool, stable bool) {
+
+	stable = true
+
+	// Transitions are computed from the beginning, so started schedules keep their real start
+	for _, transition := range GetTransitions(schedules, time.Time{}) {
+
+		if transition.Time.Sub(currentTime).Abs() < ReconciliationGracePeriod {
+			stable = false
+		}
+
+		if !transition.Time.After(currentTime) {
+			turnOn = transition.TurnOn
+		}
+	}
+
+	return turnOn, stable
+}
+
+// GetReconciliationInterval return the interval defined on 'device.reconciliation.interval' or the default one
+func GetReconciliationInterval(ctx *v1alpha1.Context) (interval time.Duration, err error) {
+
+	if ctx.Config.Spec.Device.Reconciliation.Interval == "" {
+		return DefaultReconciliationInterval, nil
+	}
+
+	interval, err = time.ParseDuration(ctx.Config.Spec.Device.Reconciliation.Interval)
+	if err != nil {
+		return interval, errors.New(fmt.Sprintf(ReconciliationIntervalParsingErrorMessage, err))
+	}
+
+	if interval <= 0 {
+		return interval, errors.New(fmt.Sprintf(ReconciliationIntervalParsingErrorMessage, "it must be positive"))
+	}
+
+	return interval, nil
+}
+
+// Run create a goroutine that periodically reconciles the device, when any configured integration
+// is able to report its state. The goroutine is added to the given group, and it's stopped with the context
+func (r *Reconciler) Run(ctx *v1alpha1.Context, actions *sync.WaitGroup) {
+
+	interval, err := GetReconciliationInterval(ctx)
+	if err != nil {
+		ctx.Logger.Fatal(err)
+	}
+
+	// Webhooks are fire-and-forget, so only devices able to report their state are reconciled
+	if reflect.ValueOf(ctx.Config.Spec.Device.Integrations.TapoSmartPlug).IsZero() {
+		ctx.Logger.Infof(ReconcilerNotSupportedMessage)
+		return
+	}
+
+	ctx.Logger.Infof(ReconcilerStartedMessage, interval)
+
+	actions.Add(1)
+	ctx.Clock.Go(func() {
+		defer actions.Done()
+
+		for ctx.Clock.Sleep(ctx.Context, interval) {
+			r.Reconcile(ctx)
+		}
+	})
+}
+
+// Reconcile read the state of the device and re-apply the one expected by the plan when they differ
+func (r *Reconciler) Reconcile(ctx *v1alpha1.Context) {
+
+	// Nothing is expected until the first plan is scheduled
+	schedules, found := r.plans.GetSchedules()
+	if !found {
+		return
+	}
+
+	currentTime := ctx.Clock.Now()
+
+	turnOn, stable := GetDesiredState(schedules, currentTime)
+	if !stable {
+		return
+	}
+
+	deviceOn, err := r.device.IsOn(ctx)
+	if err != nil {
+		ctx.Logger.Infof(TapoStateReadFailedErrorMessage, err)
+		return
+	}
+
+	if deviceOn == turnOn {
+		return
+	}
+
+	if turnOn {
+		r.device.TurnOn(ctx)
+	} else {
+		r.device.TurnOff(ctx)
+	}
+	r.plans.RecordTransition(ctx, Transition{Time: ctx.Clock.Now(), TurnOn: turnOn})
+
+	r.mutex.Lock()
+	r.corrections++
+	corrections := r.corrections
+	r.mutex.Unlock()
+
+	ctx.Logger.Infof(DriftCorrectedMessage, getStateName(deviceOn), getStateName(turnOn), corrections)
+}
+
+// Corrections return the number of drifts corrected since the process started
+func (r *Reconciler) Corrections() int {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.corrections
+}
+
+// getStateName return a human-readable name for the given state of the device
+func getStateName(deviceOn bool) string {
+	if deviceOn {
+		return "turned on"
+	}
+	return "turned off"
+}
@@ -0,0 +1,165 @@
+package schedules
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+	"github.com/achetronic/autoheater/internal/price"
+	"go.uber.org/zap"
+)
+
+// stubDevice represents a device whose state is kept in memory, recording the actions received
+type stubDevice struct {
+	on      bool
+	err     error
+	actions []bool
+}
+
+func (d *stubDevice) IsOn(ctx *v1alpha1.Context) (bool, error) {
+	return d.on, d.err
+}
+
+func (d *stubDevice) TurnOn(ctx *v1alpha1.Context) {
+	d.on = true
+	d.actions = append(d.actions, true)
+}
+
+func (d *stubDevice) TurnOff(ctx *v1alpha1.Context) {
+	d.on = false
+	d.actions = append(d.actions, false)
+}
+
+func TestGetDesiredState(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	schedules := []price.Schedule{getPlanSchedule(start, time.Hour, 3*time.Hour)}
+
+	tests := map[string]struct {
+		offset         time.Duration
+		expectedTurnOn bool
+		expectedStable bool
+	}{
+		"before the schedule": {
+			offset:         30 * time.Minute,
+			expectedStable: true,
+		},
+		"inside the schedule": {
+			offset:         2 * time.Hour,
+			expectedTurnOn: true,
+			expectedStable: true,
+		},
+		"after the schedule": {
+			offset:         4 * time.Hour,
+			expectedStable: true,
+		},
+		"close to the start": {
+			offset:         time.Hour - 30*time.Second,
+			expectedStable: false,
+		},
+		"close to the stop": {
+			offset:         3*time.Hour + 30*time.Second,
+			expectedStable: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			turnOn, stable := GetDesiredState(schedules, start.Add(test.offset))
+
+			if stable != test.expectedStable {
+				t.Fatalf("expected stable %t, got %t", test.expectedStable, stable)
+			}
+
+			if stable && turnOn != test.expectedTurnOn {
+				t.Errorf("expected turn on %t, got %t", test.expectedTurnOn, turnOn)
+			}
+		})
+	}
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	schedules := []price.Schedule{getPlanSchedule(start, time.Hour, 3*time.Hour)}
+
+	tests := map[string]struct {
+		withoutPlan bool
+		offset      time.Duration
+		device      stubDevice
+
+		// Actions expected on the device. True means turning it on
+		expectedActions []bool
+	}{
+		"device turned on outside the schedules is turned off": {
+			offset:          4 * time.Hour,
+			device:          stubDevice{on: true},
+			expectedActions: []bool{false},
+		},
+		"device turned off inside a schedule is turned on": {
+			offset:          2 * time.Hour,
+			device:          stubDevice{on: false},
+			expectedActions: []bool{true},
+		},
+		"device in the expected state": {
+			offset: 2 * time.Hour,
+			device: stubDevice{on: true},
+		},
+		"moment close to a transition": {
+			offset: time.Hour + 30*time.Second,
+			device: stubDevice{on: false},
+		},
+		"state of the device not available": {
+			offset: 2 * time.Hour,
+			device: stubDevice{err: errors.New("unreachable")},
+		},
+		"no plan scheduled yet": {
+			withoutPlan: true,
+			offset:      4 * time.Hour,
+			device:      stubDevice{on: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := &v1alpha1.Context{
+				Config: &v1alpha1.ConfigSpec{},
+				Logger: zap.NewNop().Sugar(),
+				Clock:  clocktest.NewFakeClock(start.Add(test.offset)),
+			}
+
+			plans := NewPlanStore()
+			if !test.withoutPlan {
+				plans.Save(ctx, PlanState{WindowStart: start, WindowEnd: start.AddDate(0, 0, 1), Schedules: schedules})
+			}
+
+			device := test.device
+			reconciler := NewReconciler(&device, plans)
+			reconciler.Reconcile(ctx)
+
+			if len(device.actions) != len(test.expectedActions) {
+				t.Fatalf("expected the actions %v, got %v", test.expectedActions, device.actions)
+			}
+
+			for index, action := range test.expectedActions {
+				if device.actions[index] != action {
+					t.Errorf("expected the actions %v, got %v", test.expectedActions, device.actions)
+				}
+			}
+
+			if reconciler.Corrections() != len(test.expectedActions) {
+				t.Errorf("expected %d corrections, got %d", len(test.expectedActions), reconciler.Corrections())
+			}
+
+			// Corrections are recorded as executed transitions of the plan
+			if !test.withoutPlan {
+				plan := plans.plan
+				if len(plan.Executed) != len(test.expectedActions) {
+					t.Errorf("expected %d transitions recorded, got %v", len(test.expectedActions), plan.Executed)
+				}
+			}
+		})
+	}
+}
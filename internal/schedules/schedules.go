@@ -1,16 +1,23 @@
 package schedules
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock"
+	"github.com/achetronic/autoheater/internal/globals"
 	"github.com/achetronic/autoheater/internal/integrations/taposmartplug"
 	"github.com/achetronic/autoheater/internal/integrations/webhook"
 	"github.com/achetronic/autoheater/internal/price"
 	"github.com/achetronic/autoheater/internal/weather"
-	"github.com/achetronic/autoheater/internal/globals"
 )
 
 const (
@@ -18,43 +25,261 @@ const (
 	RetryAttempts = 3
 	RetryDelay    = 5 * time.Second
 
+	// Prices for tomorrow are published once a day, so they are polled for a while when planning across midnight
+	AvailabilityRetryAttempts = 16
+	AvailabilityRetryDelay    = 15 * time.Minute
+
+	// Windows are planned a bit after their start to avoid collisions with the previous one
+	PlanningDelay = 1 * time.Minute
+
 	//
-	RootSchedulerStartedMessage = "task scheduler is running @ %s"
-	WaitingNextDayMessage       = "waiting until next day to schedule actions"
-	WeatherNotSuitableMessage   = "weather is not suitable to turn on the device"
+	horizonTimeLayout = "15:04"
+
+	// Possible values for 'device.shutdownPolicy'
+	ShutdownPolicyTurnOff   = "turnOff"
+	ShutdownPolicyLeaveAsIs = "leaveAsIs"
 
-	StartDeviceProgrammedActionMessage = "task programmed. device will be turned on @ %s"
+	//
+	RootSchedulerStartedMessage    = "task scheduler is running @ %s"
+	CostEstimationMessage          = "estimated consumption: %.2f kWh. cost: %.2f, baseline cost: %.2f, savings: %.2f"
+	WaitingNextDayMessage          = "waiting until next day to schedule actions"
+	WaitingNextWindowMessage       = "waiting until %s to schedule actions for the next window"
+	PlanningWindowMessage          = "planning window from %s to %s"
+	PricesIncompleteMessage        = "prices for the whole window are not published yet, retrying in %s"
+	WeatherNotSuitableMessage      = "weather is not suitable to turn on the device"
+	ScaledActiveDurationMessage    = "active duration scaled to %s according to the weather forecast"
+	CopForecastNotAvailableMessage = "forecast not available to weight the prices by config.device.cop: %s"
+
+	NoSchedulesMessage                 = "no task programmed. device will be kept turned off"
+	StartDeviceProgrammedActionMessage = "task programmed. device will be turned on @ %s. reasons: %s"
 	StartDeviceExecutedActionMessage   = "task completed. device has been turned on @ %s"
 
 	// --
 	StopDeviceProgrammedActionMessage = "task programmed. device will be turned off @ %s"
 	StopDeviceExecutedActionMessage   = "task completed. device has been turned off @ %s"
 
+	// --
+	ShutdownRequestedMessage = "shutdown requested. pending tasks have been cancelled"
+	ShutdownTurnOffMessage   = "device has been requested to turn off before exiting"
+	ShutdownLeaveAsIsMessage = "device has been left as it is before exiting"
+
 	WeatherNotAvailableErrorMessage         = "impossible to determine whether it's cold in your coordinates"
+	HorizonTimeParsingErrorMessage          = "config.global.horizon fields must be times with the format HH:MM: %s"
+	HorizonProviderNotSupportedErrorMessage = "config.global.horizon can not cross midnight using only 'apagaluz' " +
+		"price provider, as it does not publish tomorrow's prices. Select a provider publishing them, like esios, " +
+		"or chain one of them on config.price.providers"
+	ShutdownPolicyNotSupportedErrorMessage  = "config.device.shutdownPolicy field must be one of: turnOff, leaveAsIs"
 	TapoStartExecutionFailedErrorMessage    = "error executing start action for 'tapo smartplug' integration: %s"
 	TapoStopExecutionFailedErrorMessage     = "error executing stop action for 'tapo smartplug' integration: %s"
 	WebhookStartExecutionFailedErrorMessage = "error executing start action for 'webhook' integration: %s"
 	WebhookStopExecutionFailedErrorMessage  = "error executing stop action for 'tapo webhook' integration: %s"
+	WebhookPlanExecutionFailedErrorMessage  = "error executing plan action for 'webhook' integration: %s"
 )
 
+// PlanningWindow represents a range of time whose cheapest hours are selected at once
+type PlanningWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// GetPlanningWindow return the planning window containing the given moment, or the next one when the moment
+// is not covered by any window.
+// By default, windows are whole days. When 'global.horizon' is enabled, they start at 'horizon.start' and finish
+// at the following 'horizon.end', crossing midnight when needed
+func GetPlanningWindow(ctx *v1alpha1.Context, currentTime time.Time) (window PlanningWindow, err error) {
+
+	currentTime = currentTime.In(time.Local)
+	today := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, time.Local)
+
+	if !ctx.Config.Spec.Global.Horizon.Enabled {
+		window.Start = today
+		window.End = today.AddDate(0, 0, 1)
+		return window, nil
+	}
+
+	horizonStart, err := time.Parse(horizonTimeLayout, ctx.Config.Spec.Global.Horizon.Start)
+	if err != nil {
+		return window, errors.New(fmt.Sprintf(HorizonTimeParsingErrorMessage, err))
+	}
+
+	horizonEnd, err := time.Parse(horizonTimeLayout, ctx.Config.Spec.Global.Horizon.End)
+	if err != nil {
+		return window, errors.New(fmt.Sprintf(HorizonTimeParsingErrorMessage, err))
+	}
+
+	// Look for the window starting yesterday first, as it can be still running
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		window.Start = time.Date(day.Year(), day.Month(), day.Day(), horizonStart.Hour(), horizonStart.Minute(), 0, 0, time.Local)
+		window.End = time.Date(day.Year(), day.Month(), day.Day(), horizonEnd.Hour(), horizonEnd.Minute(), 0, 0, time.Local)
+
+		// Windows ending before their start finish the following day
+		if !window.End.After(window.Start) {
+			window.End = time.Date(day.Year(), day.Month(), day.Day()+1, horizonEnd.Hour(), horizonEnd.Minute(), 0, 0, time.Local)
+		}
+
+		if currentTime.Before(window.End) {
+			return window, nil
+		}
+	}
+
+	// Already finished for today, so the next one starts tomorrow
+	return GetPlanningWindow(ctx, today.AddDate(0, 0, 1))
+}
+
+// CheckHorizon return an error when 'global.horizon' is not valid.
+// Windows crossing midnight need tomorrow's prices, so they can not be planned when ApagaLuz is the only provider,
+// as it just publishes today's ones
+func CheckHorizon(ctx *v1alpha1.Context) error {
+
+	if !ctx.Config.Spec.Global.Horizon.Enabled {
+		return nil
+	}
+
+	horizonStart, err := time.Parse(horizonTimeLayout, ctx.Config.Spec.Global.Horizon.Start)
+	if err != nil {
+		return errors.New(fmt.Sprintf(HorizonTimeParsingErrorMessage, err))
+	}
+
+	horizonEnd, err := time.Parse(horizonTimeLayout, ctx.Config.Spec.Global.Horizon.End)
+	if err != nil {
+		return errors.New(fmt.Sprintf(HorizonTimeParsingErrorMessage, err))
+	}
+
+	// Windows finishing at midnight are covered by today's prices
+	if horizonEnd.After(horizonStart) || (horizonEnd.Hour() == 0 && horizonEnd.Minute() == 0) {
+		return nil
+	}
+
+	for _, providerName := range price.GetProviderNames(ctx) {
+		if providerName != price.ProviderApagaLuz {
+			return nil
+		}
+	}
+
+	return errors.New(HorizonProviderNotSupportedErrorMessage)
+}
+
 // RunScheduler run scheduling function periodically.
-// It's executed always in the beginning of the day as it's the moment when the PVPC prices are really known
+// It's executed always in the beginning of each planning window as it's the moment when the prices are really known.
+// It returns when the context is cancelled, after cancelling pending actions and applying 'device.shutdownPolicy'
 func RunScheduler(ctx *v1alpha1.Context) {
 
 	var err error
 	var retryFunctionErr error
 
-	var isCold bool
+	if ctx.Context == nil {
+		ctx.Context = context.Background()
+	}
+
+	if ctx.Clock == nil {
+		ctx.Clock = clock.SystemClock{}
+	}
+
+	switch ctx.Config.Spec.Device.ShutdownPolicy {
+	case "", ShutdownPolicyTurnOff, ShutdownPolicyLeaveAsIs:
+	default:
+		ctx.Logger.Fatal(ShutdownPolicyNotSupportedErrorMessage)
+	}
+
+	err = CheckHorizon(ctx)
+	if err != nil {
+		ctx.Logger.Fatal(err)
+	}
+
+	err = price.CheckBaseline(ctx)
+	if err != nil {
+		ctx.Logger.Fatal(err)
+	}
+
+	err = price.CheckCop(ctx)
+	if err != nil {
+		ctx.Logger.Fatal(err)
+	}
+
+	if ctx.Config.Spec.Weather.Enabled {
+		err = weather.CheckTemperature(ctx)
+		if err != nil {
+			ctx.Logger.Fatal(err)
+		}
+	}
+
+	// Goroutines executing the actions, waited before exiting
+	var actions sync.WaitGroup
+
+	// Plan being executed, shared with the goroutines recording its transitions
+	plans := NewPlanStore()
+
+	if ctx.Config.Spec.Device.Reconciliation.Enabled {
+		NewReconciler(IntegrationsDevice{}, plans).Run(ctx, &actions)
+	}
+
+	var decision weather.Decision
+	var activeDuration time.Duration
 	var schedules []price.Schedule
+	var estimation price.CostEstimation
+	var temperatures []price.Temperature
+	var window PlanningWindow
+	var nextWindow PlanningWindow
 
 	for {
-		currentTime := time.Now().In(time.Local)
+		currentTime := ctx.Clock.Now().In(time.Local)
+
+		window, err = GetPlanningWindow(ctx, currentTime)
+		if err != nil {
+			ctx.Logger.Fatal(err)
+		}
+
+		// The window is not started yet, so wait for it
+		if currentTime.Before(window.Start) {
+			nextWindow = window
+			goto waitNextWindow
+		}
+
+		ctx.Logger.Infof(PlanningWindowMessage, window.Start.Format(time.RFC822), window.End.Format(time.RFC822))
+
+		// Resume the plan stored for this window, so a restarted process does not craft a different one
+		if plan, found := plans.Load(ctx, window); found {
+			schedules = ResumePlan(ctx, plans, plan, currentTime)
+			ScheduleActions(ctx, plans, schedules, &actions)
+			goto waitNextDay
+		}
+
+		// Scale the active duration according to the forecast, instead of just enabling or disabling the scheduler.
+		// Otherwise, the one defined on config is used
+		if !ctx.Config.Spec.Weather.Enabled || !ctx.Config.Spec.Weather.Scaling.Enabled {
+			activeDuration, err = price.GetActiveDuration(ctx)
+			if err != nil {
+				ctx.Logger.Fatal(err)
+			}
+		} else {
+
+			retryFunctionErr = globals.RetryContext(ctx.Context, ctx.Clock, func() error {
+				activeDuration, err = weather.GetScaledActiveDuration(ctx, window.Start, window.End)
+				return err
+			}, RetryAttempts, RetryDelay)
+
+			if retryFunctionErr != nil {
+				ctx.Logger.Infof(WeatherNotAvailableErrorMessage)
+				goto waitNextDay
+			}
+
+			if activeDuration > price.MaxActiveDuration {
+				activeDuration = price.MaxActiveDuration
+			}
+
+			ctx.Logger.Infof(ScaledActiveDurationMessage, activeDuration)
+
+			if activeDuration < time.Minute {
+				ctx.Logger.Infof(WeatherNotSuitableMessage)
+				goto waitNextDay
+			}
+		}
 
 		// Disable the scheduler in (hot days for heaters) && (cold days for coolers)
-		if ctx.Config.Spec.Weather.Enabled {
+		if ctx.Config.Spec.Weather.Enabled && !ctx.Config.Spec.Weather.Scaling.Enabled {
 
-			retryFunctionErr = globals.Retry(func() error {
-				isCold, err = weather.IsColdDay(ctx)
+			retryFunctionErr = globals.RetryContext(ctx.Context, ctx.Clock, func() error {
+				decision, err = weather.IsColdDay(ctx, window.Start, window.End)
 				return err
 			}, RetryAttempts, RetryDelay)
 
@@ -63,107 +288,246 @@ func RunScheduler(ctx *v1alpha1.Context) {
 				goto waitNextDay
 			}
 
+			// Decision is stored once taken, so the next evaluation keeps it inside the hysteresis band
+			weather.SaveDecision(ctx, decision)
+
 			// Warm day, not enable the heater
 			// Cold day, not enable the cooler
-			if (ctx.Config.Spec.Device.Type == "heater" && !isCold) ||
-				(ctx.Config.Spec.Device.Type == "cooler" && isCold) {
+			if (ctx.Config.Spec.Device.Type == "heater" && !decision.Cold) ||
+				(ctx.Config.Spec.Device.Type == "cooler" && decision.Cold) {
 				ctx.Logger.Infof(WeatherNotSuitableMessage)
 				goto waitNextDay
 			}
 		}
 
 		// Get the sections with the best prices to satisfy the hours required by the user
-		retryFunctionErr = globals.Retry(func() error {
-			schedules, err = price.GetBestSchedules(ctx)
+		temperatures = GetCopTemperatures(ctx)
+		retryFunctionErr = globals.RetryContext(ctx.Context, ctx.Clock, func() error {
+			schedules, estimation, err = price.GetBestSchedules(ctx, window.Start, window.End, activeDuration, temperatures)
 			return err
 		}, RetryAttempts, RetryDelay)
 
+		// Prices for tomorrow may not be published yet when the window crosses midnight, so wait for them
+		if errors.Is(retryFunctionErr, price.ErrPricesIncomplete) && ctx.Config.Spec.Global.Horizon.Enabled {
+			retryFunctionErr = globals.RetryContext(ctx.Context, ctx.Clock, func() error {
+				schedules, estimation, err = price.GetBestSchedules(ctx, window.Start, window.End, activeDuration, temperatures)
+				if errors.Is(err, price.ErrPricesIncomplete) {
+					ctx.Logger.Infof(PricesIncompleteMessage, AvailabilityRetryDelay)
+				}
+				return err
+			}, AvailabilityRetryAttempts, AvailabilityRetryDelay)
+		}
+
 		if retryFunctionErr != nil {
 			ctx.Logger.Infof(price.PricesNotAvailableErrorMessage)
 			goto waitNextDay
 		}
 
 		//
-		ctx.Logger.Infof(RootSchedulerStartedMessage, time.Now().In(time.Local).Format(time.RFC822))
-		ScheduleActions(ctx, schedules)
+		ctx.Logger.Infof(RootSchedulerStartedMessage, ctx.Clock.Now().In(time.Local).Format(time.RFC822))
+		if ctx.Config.Spec.Device.Power > 0 {
+			ctx.Logger.Infof(CostEstimationMessage, estimation.Energy, estimation.Cost, estimation.BaselineCost, estimation.Savings)
+		}
+
+		plans.Save(ctx, PlanState{
+			WindowStart:    window.Start,
+			WindowEnd:      window.End,
+			ActiveDuration: activeDuration,
+			Schedules:      schedules,
+		})
+
+		ExecutePlanAction(ctx, schedules, estimation)
+		ScheduleActions(ctx, plans, schedules, &actions)
 
 	waitNextDay:
-		// Wait until next programmed hour (following day)
-		// By default, next scheduling moment is 12:00 AM
+		// Retries are interrupted on shutdown, so there is nothing to wait for
+		if ctx.Context.Err() != nil {
+			break
+		}
+
+		// Wait until next programmed window (following day by default)
 		ctx.Logger.Infof(WaitingNextDayMessage)
-		nextDay := currentTime.Add(24 * time.Hour)
-		nextTargetTime := time.Date(nextDay.Year(), nextDay.Month(), nextDay.Day(), 0, 1, 0, 0, time.Local)
-		duration := nextTargetTime.Sub(currentTime)
-		time.Sleep(duration)
+		nextWindow, err = GetPlanningWindow(ctx, window.End)
+		if err != nil {
+			ctx.Logger.Fatal(err)
+		}
+
+	waitNextWindow:
+		// By default, next scheduling moment is 12:01 AM
+		nextTargetTime := nextWindow.Start.Add(PlanningDelay)
+		ctx.Logger.Infof(WaitingNextWindowMessage, nextTargetTime.Format(time.RFC822))
+		if !ctx.Clock.Sleep(ctx.Context, nextTargetTime.Sub(ctx.Clock.Now())) {
+			break
+		}
 	}
+
+	ctx.Logger.Infof(ShutdownRequestedMessage)
+	actions.Wait()
+	Shutdown(ctx, plans)
 }
 
-// ScheduleActions create goroutines to execute actions delayed until moments given by schedules list.
-// To change the timezone of programmed tasks, just set TZ environment variable to desired one, i.e: TZ=Atlantic/Canary
-func ScheduleActions(ctx *v1alpha1.Context, schedules []price.Schedule) {
+// Shutdown apply the policy defined on 'device.shutdownPolicy' before exiting.
+// The device is turned off by default, so it's never left turned on in expensive hours
+func Shutdown(ctx *v1alpha1.Context, plans *PlanStore) {
+
+	if ctx.Config.Spec.Device.ShutdownPolicy == ShutdownPolicyLeaveAsIs {
+		ctx.Logger.Infof(ShutdownLeaveAsIsMessage)
+		return
+	}
 
-	// Send a signal to stop the device before scheduling new goroutines.
-	// This is to avoid keeping the device turned on in expensive hours in case this CLI failed in the middle
-	// of some time range, and restarted after the range finished
 	ExecuteStopAction(ctx)
+	plans.RecordTransition(ctx, Transition{Time: ctx.Clock.Now(), TurnOn: false})
+	ctx.Logger.Infof(ShutdownTurnOffMessage)
+}
 
-	// Get current time
-	currentTime := time.Now().In(time.Local)
+// Transition represents a moment when the device must change its state
+type Transition struct {
+	Time   time.Time `json:"time"`
+	TurnOn bool      `json:"turnOn"`
 
-	//
-	syncScheduleWait := sync.WaitGroup{}
+	// Reasons why the device is turned on until the next transition
+	Reasons []string `json:"reasons,omitempty"`
+}
 
-	// Iterate over schedules
-	for _, schedule := range schedules {
+// GetCopTemperatures return the forecast temperatures used to weight the prices by 'device.cop', when it's enabled.
+// Prices are still valid without them, so no temperatures are returned when the forecast is not available
+func GetCopTemperatures(ctx *v1alpha1.Context) (temperatures []price.Temperature) {
 
-		//
-		scheduleStartTime := schedule.Start.In(time.Local)
-		durationUntilStart := scheduleStartTime.Sub(currentTime)
+	if !ctx.Config.Spec.Device.Cop.Enabled {
+		return temperatures
+	}
 
-		scheduleStopTime := schedule.Stop.In(time.Local)
-		durationUntilStop := scheduleStopTime.Sub(currentTime)
+	forecast, err := weather.GetForecast(ctx)
+	if err != nil {
+		ctx.Logger.Infof(CopForecastNotAvailableMessage, err)
+		return temperatures
+	}
 
-		//
-		beforeTheRange := durationUntilStart > 0
-		insideTheRange := durationUntilStart < 0 && durationUntilStop > 0
+	for _, item := range forecast {
+		temperatures = append(temperatures, price.Temperature{Time: item.Time, Value: item.Temperature})
+	}
+
+	return temperatures
+}
+
+// GetTransitions return the ordered timeline of transitions needed to execute the given schedules from the given moment.
+// Schedules stopping at the same moment the next one starts are coalesced, so the device is not turned off in the middle.
+// Schedules already started are turned on immediately
+func GetTransitions(schedules []price.Schedule, currentTime time.Time) (transitions []Transition) {
+
+	sortedSchedules := append([]price.Schedule{}, schedules...)
+	sort.SliceStable(sortedSchedules, func(i, j int) bool {
+		return sortedSchedules[i].Start.Before(sortedSchedules[j].Start)
+	})
+
+	for _, schedule := range sortedSchedules {
+
+		// Passed schedules have nothing to do
+		if !schedule.Stop.After(currentTime) || !schedule.Stop.After(schedule.Start) {
+			continue
+		}
 
-		// Create a goroutine to execute some actions in desired moment (start event)
-		if beforeTheRange || insideTheRange {
-			syncScheduleWait.Add(1)
+		scheduleStart := schedule.Start
+		if scheduleStart.Before(currentTime) {
+			scheduleStart = currentTime
+		}
 
-			// Starting moment is passed, but still have time to start
-			if insideTheRange {
-				durationUntilStart = 0
+		// Adjacent or overlapping schedules only extend the previous stop
+		lastIndex := len(transitions) - 1
+		if lastIndex >= 0 && !scheduleStart.After(transitions[lastIndex].Time) {
+			if schedule.Stop.After(transitions[lastIndex].Time) {
+				transitions[lastIndex].Time = schedule.Stop
 			}
 
-			go func(startTime time.Time, startIn time.Duration) {
-				ctx.Logger.Infof(StartDeviceProgrammedActionMessage, startTime.Format(time.RFC822))
-				syncScheduleWait.Done()
+			for _, reason := range schedule.Reasons {
+				if !slices.Contains(transitions[lastIndex-1].Reasons, reason) {
+					transitions[lastIndex-1].Reasons = append(transitions[lastIndex-1].Reasons, reason)
+				}
+			}
+			continue
+		}
 
-				time.Sleep(startIn)
-				ExecuteStartAction(ctx)
+		transitions = append(transitions,
+			Transition{Time: scheduleStart, TurnOn: true, Reasons: append([]string{}, schedule.Reasons...)},
+			Transition{Time: schedule.Stop, TurnOn: false},
+		)
+	}
+
+	return transitions
+}
+
+// ScheduleActions create a goroutine that executes the actions at the moments given by schedules list.
+// All the actions are executed by the same goroutine following an ordered timeline, so a stop can never
+// be executed after the start that follows it. Executed transitions are recorded on the given plan store.
+// The goroutine is added to the given group, and pending actions are cancelled with the context
+func ScheduleActions(ctx *v1alpha1.Context, plans *PlanStore, schedules []price.Schedule, actions *sync.WaitGroup) {
+
+	// Send a signal to stop the device before scheduling new actions.
+	// This is to avoid keeping the device turned on in expensive hours in case this CLI failed in the middle
+	// of some time range, and restarted after the range finished
+	ExecuteStopAction(ctx)
+	plans.RecordTransition(ctx, Transition{Time: ctx.Clock.Now(), TurnOn: false})
+
+	transitions := GetTransitions(schedules, ctx.Clock.Now())
+	if len(transitions) == 0 {
+		ctx.Logger.Infof(NoSchedulesMessage)
+	}
 
-				ctx.Logger.Infof(StartDeviceExecutedActionMessage, startTime.Format(time.RFC822))
-			}(scheduleStartTime, durationUntilStart)
+	for _, transition := range transitions {
+		transitionTime := transition.Time.In(time.Local).Format(time.RFC822)
+
+		if transition.TurnOn {
+			ctx.Logger.Infof(StartDeviceProgrammedActionMessage, transitionTime, strings.Join(transition.Reasons, ", "))
+		} else {
+			ctx.Logger.Infof(StopDeviceProgrammedActionMessage, transitionTime)
 		}
+	}
+
+	actions.Add(1)
+	ctx.Clock.Go(func() {
+		defer actions.Done()
+
+		for _, transition := range transitions {
+			transitionTime := transition.Time.In(time.Local).Format(time.RFC822)
 
-		// Create a goroutine to execute some actions in desired moment (stop event)
-		if durationUntilStop > 0 {
-			syncScheduleWait.Add(1)
+			if !ctx.Clock.Sleep(ctx.Context, transition.Time.Sub(ctx.Clock.Now())) {
+				return
+			}
 
-			go func(stopTime time.Time, stopIn time.Duration) {
-				ctx.Logger.Infof(StopDeviceProgrammedActionMessage, stopTime.Format(time.RFC822))
-				syncScheduleWait.Done()
+			if transition.TurnOn {
+				ExecuteStartAction(ctx)
+				plans.RecordTransition(ctx, Transition{Time: ctx.Clock.Now(), TurnOn: true, Reasons: transition.Reasons})
+				ctx.Logger.Infof(StartDeviceExecutedActionMessage, transitionTime)
+				continue
+			}
 
-				time.Sleep(stopIn)
-				ExecuteStopAction(ctx)
+			ExecuteStopAction(ctx)
+			plans.RecordTransition(ctx, Transition{Time: ctx.Clock.Now(), TurnOn: false})
+			ctx.Logger.Infof(StopDeviceExecutedActionMessage, transitionTime)
+		}
+	})
+}
 
-				ctx.Logger.Infof(StopDeviceExecutedActionMessage, stopTime.Format(time.RFC822))
-			}(scheduleStopTime, durationUntilStop)
+// ExecutePlanAction execute an action for each defined integration when a new plan is crafted
+func ExecutePlanAction(ctx *v1alpha1.Context, schedules []price.Schedule, estimation price.CostEstimation) {
+
+	// Execute the action for webhook device when its config is present and plan events are requested
+	if !reflect.ValueOf(ctx.Config.Spec.Device.Integrations.Webhook).IsZero() &&
+		ctx.Config.Spec.Device.Integrations.Webhook.SendPlanEvents {
+
+		plan := webhook.PlanSpec{Estimation: estimation}
+		for _, schedule := range schedules {
+			plan.Schedules = append(plan.Schedules, webhook.PlanScheduleSpec{
+				Start:   schedule.Start.In(time.Local),
+				Stop:    schedule.Stop.In(time.Local),
+				Reasons: schedule.Reasons,
+			})
 		}
 
-		// Wait until both goroutines have been scheduled
-		syncScheduleWait.Wait()
+		_, err := webhook.SendPlanEvent(ctx, plan)
+		if err != nil {
+			ctx.Logger.Infof(WebhookPlanExecutionFailedErrorMessage, err)
+		}
 	}
 }
 
@@ -0,0 +1,117 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+func TestGetPlanningWindowDst(t *testing.T) {
+
+	tests := map[string]struct {
+		horizon  v1alpha1.HorizonSpec
+		moment   time.Time
+		start    time.Time
+		duration time.Duration
+	}{
+		"spring forward day": {
+			moment:   time.Date(2026, time.March, 29, 12, 0, 0, 0, time.Local),
+			start:    time.Date(2026, time.March, 29, 0, 0, 0, 0, time.Local),
+			duration: 23 * time.Hour,
+		},
+		"fall back day": {
+			moment:   time.Date(2026, time.October, 25, 12, 0, 0, 0, time.Local),
+			start:    time.Date(2026, time.October, 25, 0, 0, 0, 0, time.Local),
+			duration: 25 * time.Hour,
+		},
+		"spring forward night": {
+			horizon:  v1alpha1.HorizonSpec{Enabled: true, Start: "22:00", End: "06:00"},
+			moment:   time.Date(2026, time.March, 29, 1, 0, 0, 0, time.Local),
+			start:    time.Date(2026, time.March, 28, 22, 0, 0, 0, time.Local),
+			duration: 7 * time.Hour,
+		},
+		"fall back night": {
+			horizon:  v1alpha1.HorizonSpec{Enabled: true, Start: "22:00", End: "06:00"},
+			moment:   time.Date(2026, time.October, 24, 23, 0, 0, 0, time.Local),
+			start:    time.Date(2026, time.October, 24, 22, 0, 0, 0, time.Local),
+			duration: 9 * time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := &v1alpha1.Context{Config: &v1alpha1.ConfigSpec{}}
+			ctx.Config.Spec.Global.Horizon = test.horizon
+
+			window, err := GetPlanningWindow(ctx, test.moment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !window.Start.Equal(test.start) || window.End.Sub(window.Start) != test.duration {
+				t.Errorf("expected a window of %s from %s, got from %s to %s",
+					test.duration, test.start, window.Start, window.End)
+			}
+
+			// The next window starts when the current one finishes, following the calendar
+			nextWindow, err := GetPlanningWindow(ctx, window.End)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedNextStart := test.start.AddDate(0, 0, 1)
+			if !nextWindow.Start.Equal(expectedNextStart) {
+				t.Errorf("expected the next window from %s, got from %s", expectedNextStart, nextWindow.Start)
+			}
+		})
+	}
+}
+
+func TestCheckHorizon(t *testing.T) {
+
+	tests := map[string]struct {
+		horizon     v1alpha1.HorizonSpec
+		providers   []string
+		expectError bool
+	}{
+		"disabled horizon": {
+			horizon: v1alpha1.HorizonSpec{Start: "20:00", End: "08:00"},
+		},
+		"window crossing midnight with the default provider": {
+			horizon:     v1alpha1.HorizonSpec{Enabled: true, Start: "20:00", End: "08:00"},
+			expectError: true,
+		},
+		"window crossing midnight with a provider publishing tomorrow's prices": {
+			horizon:   v1alpha1.HorizonSpec{Enabled: true, Start: "20:00", End: "08:00"},
+			providers: []string{"apagaluz", "esios"},
+		},
+		"window finishing at midnight": {
+			horizon: v1alpha1.HorizonSpec{Enabled: true, Start: "08:00", End: "00:00"},
+		},
+		"window inside the same day": {
+			horizon: v1alpha1.HorizonSpec{Enabled: true, Start: "08:00", End: "20:00"},
+		},
+		"invalid times": {
+			horizon:     v1alpha1.HorizonSpec{Enabled: true, Start: "8h", End: "20:00"},
+			providers:   []string{"esios"},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := &v1alpha1.Context{Config: &v1alpha1.ConfigSpec{}}
+			ctx.Config.Spec.Global.Horizon = test.horizon
+			ctx.Config.Spec.Price.Providers = test.providers
+
+			err := CheckHorizon(ctx)
+			if test.expectError && err == nil {
+				t.Errorf("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("expected no error, got '%s'", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package schedules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+	"go.uber.org/zap"
+)
+
+const (
+	// Timezone of the simulations, as it changes the time twice a year
+	simulationTimezone = "Europe/Madrid"
+
+	// Layout of the hourly times of the fake Open-Meteo server
+	simulationOpenMeteoLayout = "2006-01-02T15:04"
+)
+
+// simulatedEvent represents an event received by the fake webhook integration
+type simulatedEvent struct {
+	Event string
+	Time  time.Time
+}
+
+// simulation represents the fake servers and the context used to run the scheduler over simulated days
+type simulation struct {
+	t     *testing.T
+	ctx   *v1alpha1.Context
+	clock *clocktest.FakeClock
+
+	mutex  sync.Mutex
+	events []simulatedEvent
+
+	// Temperature returned by the fake weather server for each local date (YYYY-MM-DD). 10ºC by default
+	temperatures map[string]float64
+}
+
+func TestMain(m *testing.M) {
+
+	location, err := time.LoadLocation(simulationTimezone)
+	if err != nil {
+		panic(err)
+	}
+
+	// The scheduler plans whole days in local time
+	time.Local = location
+
+	os.Exit(m.Run())
+}
+
+// simulationPrice return the price of the hour starting at the given instant.
+// Hours from 00:00 to 03:00 UTC are the cheapest ones, so they are always crossing the DST changes in Spain
+func simulationPrice(instant time.Time) float64 {
+
+	if instant.UTC().Hour() < 3 {
+		return 0.05
+	}
+
+	return 0.20 + float64(instant.UTC().Hour())/1000
+}
+
+// newSimulation return a simulation starting at the given moment, with fake price, weather and webhook servers
+func newSimulation(t *testing.T, start time.Time) *simulation {
+
+	s := &simulation{
+		t:            t,
+		clock:        clocktest.NewFakeClock(start),
+		temperatures: map[string]float64{},
+	}
+
+	// Prices for every hour from some days before the start to some days after, as a generic provider
+	priceServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []map[string]interface{}
+
+		for instant := start.Add(-72 * time.Hour).Truncate(time.Hour); instant.Before(start.Add(10 * 24 * time.Hour)); instant = instant.Add(time.Hour) {
+			items = append(items, map[string]interface{}{
+				"time":  instant.In(time.Local).Format(time.RFC3339),
+				"price": simulationPrice(instant),
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"prices": items})
+	}))
+	t.Cleanup(priceServer.Close)
+
+	// Hourly temperatures in GMT around the current day, as Open-Meteo does
+	weatherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := s.clock.Now().UTC()
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+		var times []string
+		var temperatures []float64
+		for instant := day.AddDate(0, 0, -1); instant.Before(day.AddDate(0, 0, 3)); instant = instant.Add(time.Hour) {
+			temperature, found := s.temperatures[instant.In(time.Local).Format(time.DateOnly)]
+			if !found {
+				temperature = 10
+			}
+
+			times = append(times, instant.Format(simulationOpenMeteoLayout))
+			temperatures = append(temperatures, temperature)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"hourly": map[string]interface{}{"time": times, "temperature_2m": temperatures},
+		})
+	}))
+	t.Cleanup(weatherServer.Close)
+
+	// Events sent to the device, recorded at the simulated moment
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		s.mutex.Lock()
+		s.events = append(s.events, simulatedEvent{Event: fmt.Sprint(payload["event"]), Time: s.clock.Now()})
+		s.mutex.Unlock()
+	}))
+	t.Cleanup(webhookServer.Close)
+
+	config := &v1alpha1.ConfigSpec{}
+	config.Metadata.Name = "simulation"
+	config.Spec.Device.Type = "heater"
+	config.Spec.Device.ActiveDuration = "3h"
+	config.Spec.Device.Integrations.Webhook.URL = webhookServer.URL
+	config.Spec.Price.Provider = "genericHttp"
+	config.Spec.Price.GenericHttp.URL = priceServer.URL
+	config.Spec.Price.GenericHttp.Mapping.Items = "prices"
+	config.Spec.Price.GenericHttp.Mapping.Fields.Timestamp = "time"
+	config.Spec.Price.GenericHttp.Mapping.Fields.Price = "price"
+	config.Spec.Weather.OpenMeteo.URL = weatherServer.URL
+	config.Spec.Weather.Coordinates.Latitude = 40.4168
+	config.Spec.Weather.Coordinates.Longitude = -3.7038
+	config.Spec.Weather.Temperature.Type = "real"
+	config.Spec.Weather.Temperature.Unit = "celsius"
+	threshold := 15.0
+	config.Spec.Weather.Temperature.Threshold = &threshold
+
+	s.ctx = &v1alpha1.Context{
+		Config:         config,
+		Logger:         zap.NewNop().Sugar(),
+		StateDirectory: t.TempDir(),
+		Clock:          s.clock,
+		HttpClient:     &http.Client{Timeout: 5 * time.Second},
+	}
+
+	return s
+}
+
+// run execute the scheduler from the start of the simulation until the given moment, and shut it down
+func (s *simulation) run(until time.Time) {
+
+	schedulerContext, cancel := context.WithCancel(context.Background())
+	s.ctx.Context = schedulerContext
+
+	finished := make(chan struct{})
+	s.clock.Go(func() {
+		RunScheduler(s.ctx)
+		close(finished)
+	})
+
+	// The clock moves forward once the scheduler and the goroutines executing its actions are sleeping
+	s.clock.RunUntil(until)
+
+	cancel()
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		s.t.Fatal("scheduler did not finish after cancelling its context")
+	}
+}
+
+// getRunIntervals return the ranges of time when the device was turned on, according to the received events
+func (s *simulation) getRunIntervals() (intervals [][2]time.Time) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var onSince *time.Time
+	for index, event := range s.events {
+		if event.Event == "start" && onSince == nil {
+			onSince = &s.events[index].Time
+		}
+
+		if event.Event == "stop" && onSince != nil {
+			intervals = append(intervals, [2]time.Time{*onSince, event.Time})
+			onSince = nil
+		}
+	}
+
+	return intervals
+}
+
+// assertRunIntervals check that the device was turned on from 00:00 to 03:00 UTC on each one of the given dates
+func (s *simulation) assertRunIntervals(dates ...string) {
+
+	intervals := s.getRunIntervals()
+	if len(intervals) != len(dates) {
+		s.t.Fatalf("expected %d run intervals, got %d: %v", len(dates), len(intervals), intervals)
+	}
+
+	for index, date := range dates {
+		expectedStart, _ := time.Parse(time.DateOnly, date)
+		expectedStop := expectedStart.Add(3 * time.Hour)
+
+		if !intervals[index][0].Equal(expectedStart) || !intervals[index][1].Equal(expectedStop) {
+			s.t.Errorf("expected run interval from %s to %s, got from %s to %s",
+				expectedStart, expectedStop, intervals[index][0].UTC(), intervals[index][1].UTC())
+		}
+	}
+}
+
+// assertPlanningMoments check that a plan was crafted at the given local moments, as the device is turned off then
+func (s *simulation) assertPlanningMoments(moments ...time.Time) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, moment := range moments {
+		found := false
+		for _, event := range s.events {
+			if event.Event == "stop" && event.Time.Equal(moment) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			s.t.Errorf("expected a plan crafted at %s", moment)
+		}
+	}
+}
+
+func TestSimulationSpringForward(t *testing.T) {
+
+	// Sunday 29th of March 2026 has 23 hours in Spain: 02:00 CET is 03:00 CEST
+	s := newSimulation(t, time.Date(2026, time.March, 28, 0, 0, 30, 0, time.Local))
+	s.run(time.Date(2026, time.March, 31, 0, 30, 0, 0, time.Local))
+
+	s.assertRunIntervals("2026-03-28", "2026-03-29", "2026-03-30")
+	s.assertPlanningMoments(
+		time.Date(2026, time.March, 29, 0, 1, 0, 0, time.Local),
+		time.Date(2026, time.March, 30, 0, 1, 0, 0, time.Local),
+		time.Date(2026, time.March, 31, 0, 1, 0, 0, time.Local),
+	)
+}
+
+func TestSimulationFallBack(t *testing.T) {
+
+	// Sunday 25th of October 2026 has 25 hours in Spain: 03:00 CEST is 02:00 CET
+	s := newSimulation(t, time.Date(2026, time.October, 24, 0, 0, 30, 0, time.Local))
+	s.run(time.Date(2026, time.October, 27, 0, 30, 0, 0, time.Local))
+
+	s.assertRunIntervals("2026-10-24", "2026-10-25", "2026-10-26")
+	s.assertPlanningMoments(
+		time.Date(2026, time.October, 25, 0, 1, 0, 0, time.Local),
+		time.Date(2026, time.October, 26, 0, 1, 0, 0, time.Local),
+		time.Date(2026, time.October, 27, 0, 1, 0, 0, time.Local),
+	)
+}
+
+func TestSimulationWeather(t *testing.T) {
+
+	// The heater is not turned on during warm days
+	s := newSimulation(t, time.Date(2026, time.January, 12, 0, 0, 30, 0, time.Local))
+	s.ctx.Config.Spec.Weather.Enabled = true
+	s.temperatures["2026-01-13"] = 20
+
+	s.run(time.Date(2026, time.January, 15, 0, 30, 0, 0, time.Local))
+
+	s.assertRunIntervals("2026-01-12", "2026-01-14")
+}
+
+func TestSimulationShutdown(t *testing.T) {
+
+	// The device is turned off when the process finishes in the middle of a schedule
+	s := newSimulation(t, time.Date(2026, time.January, 12, 0, 0, 30, 0, time.Local))
+	s.run(time.Date(2026, time.January, 12, 2, 0, 0, 0, time.Local))
+
+	intervals := s.getRunIntervals()
+	expectedStart := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	expectedStop := time.Date(2026, time.January, 12, 2, 0, 0, 0, time.Local)
+
+	if len(intervals) != 1 || !intervals[0][0].Equal(expectedStart) || !intervals[0][1].Equal(expectedStop) {
+		t.Fatalf("expected a run interval from %s to %s, got %v", expectedStart, expectedStop, intervals)
+	}
+}
+
+func TestSimulationApagaLuz(t *testing.T) {
+
+	// ApagaLuz publishes the hours of the current day by their wall clock, so 02:00 comes twice on 25th of October
+	s := newSimulation(t, time.Date(2026, time.October, 24, 0, 0, 30, 0, time.Local))
+
+	apagaLuzServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := s.clock.Now().In(time.Local)
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+		var items []map[string]interface{}
+		for instant := day; instant.Before(day.AddDate(0, 0, 1)); instant = instant.Add(time.Hour) {
+			items = append(items, map[string]interface{}{
+				"day":   instant.Format("02/01/2006"),
+				"hour":  instant.Hour(),
+				"price": simulationPrice(instant),
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(apagaLuzServer.Close)
+
+	s.ctx.Config.Spec.Price.Provider = "apagaluz"
+	s.ctx.Config.Spec.Price.ApagaLuz.URL = apagaLuzServer.URL
+
+	s.run(time.Date(2026, time.October, 27, 0, 30, 0, 0, time.Local))
+
+	s.assertRunIntervals("2026-10-24", "2026-10-25", "2026-10-26")
+}
+
+func TestSimulationPricesNotPublished(t *testing.T) {
+
+	// Prices for tomorrow are published at 20:45, so the window starting at 20:00 waits for them
+	s := newSimulation(t, time.Date(2026, time.January, 12, 20, 0, 30, 0, time.Local))
+	publication := time.Date(2026, time.January, 12, 20, 45, 0, 0, time.Local)
+	tomorrow := time.Date(2026, time.January, 13, 0, 0, 0, 0, time.Local)
+
+	// ENTSO-E rejects the requests with no matching data until the prices are published.
+	// Hours from 02:00 to 05:00 of tomorrow are the cheapest ones
+	entsoeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		periodStart, _ := time.Parse("200601021504", r.URL.Query().Get("periodStart"))
+		periodEnd, _ := time.Parse("200601021504", r.URL.Query().Get("periodEnd"))
+
+		if s.clock.Now().Before(publication) && periodEnd.After(tomorrow) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `<Acknowledgement_MarketDocument><Reason><code>999</code>`+
+				`<text>No matching data found for Data item ENERGY_PRICES</text></Reason></Acknowledgement_MarketDocument>`)
+			return
+		}
+
+		points := ""
+		for instant := periodStart; instant.Before(periodEnd); instant = instant.Add(time.Hour) {
+			price := 100
+			if !instant.Before(tomorrow.Add(2*time.Hour)) && instant.Before(tomorrow.Add(5*time.Hour)) {
+				price = 10
+			}
+			points += fmt.Sprintf("<Point><position>%d</position><price.amount>%d</price.amount></Point>",
+				int(instant.Sub(periodStart)/time.Hour)+1, price)
+		}
+
+		_, _ = fmt.Fprintf(w, `<Publication_MarketDocument><TimeSeries><curveType>A01</curveType><Period>`+
+			`<timeInterval><start>%s</start><end>%s</end></timeInterval><resolution>PT60M</resolution>%s`+
+			`</Period></TimeSeries></Publication_MarketDocument>`,
+			periodStart.Format("2006-01-02T15:04Z"), periodEnd.Format("2006-01-02T15:04Z"), points)
+	}))
+	t.Cleanup(entsoeServer.Close)
+
+	s.ctx.Config.Spec.Global.Horizon.Enabled = true
+	s.ctx.Config.Spec.Global.Horizon.Start = "20:00"
+	s.ctx.Config.Spec.Global.Horizon.End = "08:00"
+	s.ctx.Config.Spec.Price.Provider = "entsoe"
+	s.ctx.Config.Spec.Price.Zone = "ES"
+	s.ctx.Config.Spec.Price.Entsoe.Token = "token"
+	s.ctx.Config.Spec.Price.Entsoe.URL = entsoeServer.URL
+
+	s.run(time.Date(2026, time.January, 13, 6, 0, 0, 0, time.Local))
+
+	intervals := s.getRunIntervals()
+	expectedStart := tomorrow.Add(2 * time.Hour)
+	expectedStop := tomorrow.Add(5 * time.Hour)
+
+	if len(intervals) != 1 || !intervals[0][0].Equal(expectedStart) || !intervals[0][1].Equal(expectedStop) {
+		t.Fatalf("expected a run interval from %s to %s, got %v", expectedStart, expectedStop, intervals)
+	}
+}
@@ -0,0 +1,79 @@
+// ATTENTION:
+// Some decisions must survive restarts, so they are stored on disk inside the directory defined by '--state-dir' flag.
+// Each document is stored as JSON in a file like <directory>/<name>.json. When the directory is empty,
+// nothing is stored and documents are never found
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+// Load read the document stored with the given name into the given value.
+// It returns false when the document is not stored yet
+func Load(ctx *v1alpha1.Context, name string, value interface{}) (found bool, err error) {
+
+	if ctx.StateDirectory == "" {
+		return false, nil
+	}
+
+	stateBytes, err := os.ReadFile(getFilePath(ctx, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(stateBytes, value)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Save store the given value as the document with the given name, replacing the previous one
+func Save(ctx *v1alpha1.Context, name string, value interface{}) (err error) {
+
+	if ctx.StateDirectory == "" {
+		return nil
+	}
+
+	stateBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(getFilePath(ctx, name), stateBytes)
+}
+
+// WriteFile write the given content into the file on the given path, creating its directory when needed.
+// Content is written in a temporary file first, and moved to the final path once complete, so readers never find
+// a corrupted file when the process is interrupted in the middle of the write
+func WriteFile(filePath string, content []byte) (err error) {
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	temporaryFilePath := filePath + ".tmp"
+	err = os.WriteFile(temporaryFilePath, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryFilePath, filePath)
+}
+
+// getFilePath return the path to the file storing the document with the given name
+func getFilePath(ctx *v1alpha1.Context, name string) string {
+	return filepath.Join(ctx.StateDirectory, name+".json")
+}
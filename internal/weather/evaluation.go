@@ -0,0 +1,199 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	// Possible values for 'weather.evaluation.period'
+	EvaluationPeriodToday   = "today"
+	EvaluationPeriodNext24h = "next24h"
+	EvaluationPeriodWindow  = "window"
+
+	// Possible values for 'weather.evaluation.aggregation'
+	AggregationMean       = "mean"
+	AggregationMin        = "min"
+	AggregationMax        = "max"
+	AggregationPercentile = "percentile"
+	AggregationNightMean  = "nightMean"
+
+	//
+	evaluationTimeLayout   = "15:04"
+	defaultNightStart      = "22:00"
+	defaultNightEnd        = "07:00"
+	defaultForecastItemGap = time.Hour
+
+	//
+	EvaluationPeriodNotSupportedErrorMessage      = "config.weather.evaluation.period field must be one of: today, next24h, window"
+	EvaluationAggregationNotSupportedErrorMessage = "config.weather.evaluation.aggregation field must be one of: mean, min, max, percentile, nightMean"
+	EvaluationPercentileErrorMessage              = "config.weather.evaluation.percentile field must be a number between 0 and 100"
+	EvaluationNightParsingErrorMessage            = "config.weather.evaluation nightStart and nightEnd fields must be times with the format HH:MM: %s"
+	EvaluationForecastNotFoundErrorMessage        = "weather forecast has no temperatures to evaluate from %s to %s"
+	EvaluationNightNotFoundErrorMessage           = "weather forecast has no temperatures to evaluate during night hours"
+)
+
+// GetEvaluationPeriod return the range of time whose temperatures are evaluated, as defined on
+// 'weather.evaluation.period'. By default, it's the current day in local time. Given planning window
+// is used by 'window' period
+func GetEvaluationPeriod(ctx *v1alpha1.Context, windowStart time.Time, windowEnd time.Time) (start time.Time, end time.Time, err error) {
+
+	currentTime := ctx.Clock.Now().In(time.Local)
+
+	switch ctx.Config.Spec.Weather.Evaluation.Period {
+	case "", EvaluationPeriodToday:
+		start = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, time.Local)
+		end = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day()+1, 0, 0, 0, 0, time.Local)
+	case EvaluationPeriodNext24h:
+		start = currentTime
+		end = currentTime.Add(24 * time.Hour)
+	case EvaluationPeriodWindow:
+		start = windowStart
+		end = windowEnd
+	default:
+		return start, end, errors.New(EvaluationPeriodNotSupportedErrorMessage)
+	}
+
+	return start, end, nil
+}
+
+// GetEvaluatedForecast return the part of the forecast covering the evaluation period
+func GetEvaluatedForecast(ctx *v1alpha1.Context, windowStart time.Time, windowEnd time.Time) (forecast Forecast, err error) {
+
+	start, end, err := GetEvaluationPeriod(ctx, windowStart, windowEnd)
+	if err != nil {
+		return forecast, err
+	}
+
+	forecast, err = GetForecast(ctx)
+	if err != nil {
+		return forecast, err
+	}
+
+	forecast = forecast.Between(start, end)
+	if len(forecast) == 0 {
+		return forecast, errors.New(fmt.Sprintf(EvaluationForecastNotFoundErrorMessage,
+			start.Format(time.RFC822), end.Format(time.RFC822)))
+	}
+
+	return forecast, nil
+}
+
+// GetEvaluatedTemperature return the temperature of the evaluation period, aggregated
+// as defined on 'weather.evaluation.aggregation'. The mean is used by default
+func GetEvaluatedTemperature(ctx *v1alpha1.Context, windowStart time.Time, windowEnd time.Time) (temperature float64, err error) {
+
+	evaluationConfig := ctx.Config.Spec.Weather.Evaluation
+
+	forecast, err := GetEvaluatedForecast(ctx, windowStart, windowEnd)
+	if err != nil {
+		return temperature, err
+	}
+
+	temperatures := forecast.Temperatures()
+	sort.Float64s(temperatures)
+
+	switch evaluationConfig.Aggregation {
+	case "", AggregationMean:
+		return stat.Mean(temperatures, nil), nil
+
+	case AggregationMin:
+		return temperatures[0], nil
+
+	case AggregationMax:
+		return temperatures[len(temperatures)-1], nil
+
+	case AggregationPercentile:
+		if evaluationConfig.Percentile < 0 || evaluationConfig.Percentile > 100 {
+			return temperature, errors.New(EvaluationPercentileErrorMessage)
+		}
+		return stat.Quantile(evaluationConfig.Percentile/100, stat.Empirical, temperatures, nil), nil
+
+	case AggregationNightMean:
+		nightForecast, err := forecast.nightItems(evaluationConfig)
+		if err != nil {
+			return temperature, err
+		}
+
+		if len(nightForecast) == 0 {
+			return temperature, errors.New(EvaluationNightNotFoundErrorMessage)
+		}
+		return stat.Mean(nightForecast.Temperatures(), nil), nil
+	}
+
+	return temperature, errors.New(EvaluationAggregationNotSupportedErrorMessage)
+}
+
+// Between return the items of the forecast overlapping the range [start, end).
+// Each item lasts until the next one, and the last one lasts the same as the previous one
+func (f Forecast) Between(start time.Time, end time.Time) (result Forecast) {
+
+	for index, item := range f {
+		if item.Time.Before(end) && f.itemEnd(index).After(start) {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// itemEnd return the moment when the item at the given index stops being expected.
+// Each item lasts until the next one, and the last one lasts the same as the previous one
+func (f Forecast) itemEnd(index int) time.Time {
+
+	switch {
+	case index+1 < len(f):
+		return f[index+1].Time
+	case index > 0:
+		return f[index].Time.Add(f[index].Time.Sub(f[index-1].Time))
+	}
+
+	return f[index].Time.Add(defaultForecastItemGap)
+}
+
+// nightItems return the items of the forecast starting inside the night hours defined on 'weather.evaluation'.
+// Night hours cross midnight when their end is before their start
+func (f Forecast) nightItems(evaluationConfig v1alpha1.EvaluationSpec) (result Forecast, err error) {
+
+	nightStartConfig, nightEndConfig := defaultNightStart, defaultNightEnd
+	if evaluationConfig.NightStart != "" {
+		nightStartConfig = evaluationConfig.NightStart
+	}
+	if evaluationConfig.NightEnd != "" {
+		nightEndConfig = evaluationConfig.NightEnd
+	}
+
+	nightStart, err := time.Parse(evaluationTimeLayout, nightStartConfig)
+	if err != nil {
+		return result, errors.New(fmt.Sprintf(EvaluationNightParsingErrorMessage, err))
+	}
+
+	nightEnd, err := time.Parse(evaluationTimeLayout, nightEndConfig)
+	if err != nil {
+		return result, errors.New(fmt.Sprintf(EvaluationNightParsingErrorMessage, err))
+	}
+
+	startMinute := nightStart.Hour()*60 + nightStart.Minute()
+	endMinute := nightEnd.Hour()*60 + nightEnd.Minute()
+
+	for _, item := range f {
+		itemTime := item.Time.In(time.Local)
+		itemMinute := itemTime.Hour()*60 + itemTime.Minute()
+
+		insideNight := itemMinute >= startMinute && itemMinute < endMinute
+		if endMinute <= startMinute {
+			insideNight = itemMinute >= startMinute || itemMinute < endMinute
+		}
+
+		if insideNight {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,185 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+)
+
+// newDayForecastContext return a context whose forecast is the whole given day in local time,
+// where the temperature of each hour is the hour itself
+func newDayForecastContext(t *testing.T, day time.Time) *v1alpha1.Context {
+
+	var temperatures []float64
+	for hour := 0; hour < 24; hour++ {
+		temperatures = append(temperatures, float64(hour))
+	}
+
+	return newForecastContext(t, day, temperatures...)
+}
+
+func TestGetEvaluatedTemperatureAggregation(t *testing.T) {
+
+	day := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		evaluation v1alpha1.EvaluationSpec
+		expected   float64
+
+		// The evaluation may not be valid
+		expectError bool
+	}{
+		"mean by default": {
+			expected: 11.5,
+		},
+		"min": {
+			evaluation: v1alpha1.EvaluationSpec{Aggregation: AggregationMin},
+			expected:   0,
+		},
+		"max": {
+			evaluation: v1alpha1.EvaluationSpec{Aggregation: AggregationMax},
+			expected:   23,
+		},
+		"percentile": {
+			evaluation: v1alpha1.EvaluationSpec{Aggregation: AggregationPercentile, Percentile: 25},
+			expected:   5,
+		},
+		"night mean with the default night hours": {
+			evaluation: v1alpha1.EvaluationSpec{Aggregation: AggregationNightMean},
+			expected:   (0 + 1 + 2 + 3 + 4 + 5 + 6 + 22 + 23) / 9.0,
+		},
+		"night mean not crossing midnight": {
+			evaluation: v1alpha1.EvaluationSpec{Aggregation: AggregationNightMean, NightStart: "01:00", NightEnd: "03:00"},
+			expected:   1.5,
+		},
+		"percentile out of range": {
+			evaluation:  v1alpha1.EvaluationSpec{Aggregation: AggregationPercentile, Percentile: 120},
+			expectError: true,
+		},
+		"night hours with a wrong format": {
+			evaluation:  v1alpha1.EvaluationSpec{Aggregation: AggregationNightMean, NightStart: "10pm"},
+			expectError: true,
+		},
+		"unsupported aggregation": {
+			evaluation:  v1alpha1.EvaluationSpec{Aggregation: "median"},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDayForecastContext(t, day)
+			test.evaluation.Period = EvaluationPeriodWindow
+			ctx.Config.Spec.Weather.Evaluation = test.evaluation
+
+			temperature, err := GetEvaluatedTemperature(ctx, day, day.AddDate(0, 0, 1))
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %g", temperature)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if math.Abs(temperature-test.expected) > 1e-9 {
+				t.Errorf("expected %g, got %g", test.expected, temperature)
+			}
+		})
+	}
+}
+
+func TestGetEvaluatedTemperaturePeriod(t *testing.T) {
+
+	day := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	currentTime := day.Add(10 * time.Hour)
+
+	// Planning window from 18:00 to 06:00, when only the evening of the forecast is known
+	windowStart, windowEnd := day.Add(18*time.Hour), day.Add(30*time.Hour)
+
+	tests := map[string]struct {
+		period string
+
+		expectedStart time.Time
+		expectedEnd   time.Time
+
+		// Mean of the temperatures of the forecast inside the period
+		expectedTemperature float64
+
+		// The period may not be supported
+		expectError bool
+	}{
+		"today by default": {
+			expectedStart:       day,
+			expectedEnd:         day.AddDate(0, 0, 1),
+			expectedTemperature: 11.5,
+		},
+		"next 24 hours": {
+			period:              EvaluationPeriodNext24h,
+			expectedStart:       currentTime,
+			expectedEnd:         currentTime.Add(24 * time.Hour),
+			expectedTemperature: 16.5,
+		},
+		"planning window": {
+			period:              EvaluationPeriodWindow,
+			expectedStart:       windowStart,
+			expectedEnd:         windowEnd,
+			expectedTemperature: 20.5,
+		},
+		"unsupported period": {
+			period:      "tomorrow",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newDayForecastContext(t, day)
+			ctx.Config.Spec.Weather.Evaluation.Period = test.period
+			ctx.Clock = clocktest.NewFakeClock(currentTime)
+
+			start, end, err := GetEvaluationPeriod(ctx, windowStart, windowEnd)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the period from %s to %s", start, end)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !start.Equal(test.expectedStart) || !end.Equal(test.expectedEnd) {
+				t.Errorf("expected the period from %s to %s, got from %s to %s", test.expectedStart,
+					test.expectedEnd, start, end)
+			}
+
+			temperature, err := GetEvaluatedTemperature(ctx, windowStart, windowEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if math.Abs(temperature-test.expectedTemperature) > 1e-9 {
+				t.Errorf("expected %g, got %g", test.expectedTemperature, temperature)
+			}
+		})
+	}
+}
+
+func TestGetEvaluatedForecastNotFound(t *testing.T) {
+
+	day := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.Local)
+	ctx := newDayForecastContext(t, day)
+
+	// The forecast does not reach the day after
+	_, err := GetEvaluatedForecast(ctx, day.AddDate(0, 0, 2), day.AddDate(0, 0, 3))
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
@@ -0,0 +1,161 @@
+// ATTENTION:
+// MET Norway requires every client to identify itself through the User-Agent header, or requests are rejected.
+// Its forecast only contains the real temperature, so the apparent one is calculated from the humidity and the wind
+// [Terms] Ref: https://api.met.no/doc/TermsOfService
+// [Apparent temperature] Ref: http://www.bom.gov.au/info/thermal_stress/#atapproximation
+
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	MetnoAPIUrl = "https://api.met.no/weatherapi/locationforecast/2.0/compact"
+
+	// Sent when no User-Agent is defined on config
+	MetnoDefaultUserAgent = "autoheater github.com/achetronic/autoheater"
+
+	//
+	MetnoTimeParsingErrorMessage = "weather forecast from metno contains an invalid time: %s"
+)
+
+// MetnoResponseSpec represents the fields returned by MET Norway Locationforecast.
+// DISCLAIMER: NOT all the fields are covered. Only those needed to know the temperature
+// Ref: https://api.met.no/weatherapi/locationforecast/2.0/documentation
+type MetnoResponseSpec struct {
+	Properties struct {
+		Timeseries []MetnoTimeseriesSpec `json:"timeseries"`
+	} `json:"properties"`
+}
+
+// MetnoTimeseriesSpec TODO
+type MetnoTimeseriesSpec struct {
+	Time string `json:"time"`
+	Data struct {
+		Instant struct {
+			Details MetnoDetailsSpec `json:"details"`
+		} `json:"instant"`
+	} `json:"data"`
+}
+
+// MetnoDetailsSpec TODO
+type MetnoDetailsSpec struct {
+	AirTemperature   float64 `json:"air_temperature"`
+	RelativeHumidity float64 `json:"relative_humidity"`
+	WindSpeed        float64 `json:"wind_speed"`
+}
+
+// MetnoProvider represents a weather provider that retrieves the forecast from MET Norway
+type MetnoProvider struct {
+	ctx        *v1alpha1.Context
+	url        string
+	httpClient *http.Client
+	userAgent  string
+	latitude   float64
+	longitude  float64
+}
+
+// NewMetnoProvider return a MET Norway provider for the coordinates defined on 'weather.coordinates'
+func NewMetnoProvider(ctx *v1alpha1.Context) (provider *MetnoProvider, err error) {
+
+	err = checkCoordinates(ctx)
+	if err != nil {
+		return provider, err
+	}
+
+	provider = &MetnoProvider{
+		httpClient: httpx.GetClient(ctx),
+		ctx:        ctx,
+		url:        MetnoAPIUrl,
+		userAgent:  MetnoDefaultUserAgent,
+		latitude:   ctx.Config.Spec.Weather.Coordinates.Latitude,
+		longitude:  ctx.Config.Spec.Weather.Coordinates.Longitude,
+	}
+
+	if ctx.Config.Spec.Weather.Metno.URL != "" {
+		provider.url = ctx.Config.Spec.Weather.Metno.URL
+	}
+
+	if ctx.Config.Spec.Weather.Metno.UserAgent != "" {
+		provider.userAgent = ctx.Config.Spec.Weather.Metno.UserAgent
+	}
+
+	return provider, nil
+}
+
+// GetForecast return the temperatures for the next days, starting at the current hour.
+// Temperatures are hourly for the next hours, and spaced several hours later
+func (p *MetnoProvider) GetForecast() (forecast Forecast, err error) {
+
+	// Coordinates with more than 4 decimals are rejected by the API
+	params := url.Values{}
+	params.Add("lat", strconv.FormatFloat(p.latitude, 'f', 4, 64))
+	params.Add("lon", strconv.FormatFloat(p.longitude, 'f', 4, 64))
+
+	requestUrl, err := url.Parse(p.url)
+	if err != nil {
+		return forecast, errors.New(fmt.Sprintf(HttpUrlParsingErrorMessage, err))
+	}
+
+	requestUrl.RawQuery = params.Encode()
+
+	httpRequest, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return forecast, errors.New(fmt.Sprintf(HttpUrlParsingErrorMessage, err))
+	}
+
+	httpRequest.Header.Set("User-Agent", p.userAgent)
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return forecast, err
+	}
+
+	response := &MetnoResponseSpec{}
+	err = json.Unmarshal(body, response)
+	if err != nil {
+		return forecast, errors.New(fmt.Sprintf(ResponseDecodingErrorMessage, err))
+	}
+
+	for _, item := range response.Properties.Timeseries {
+
+		forecastTime, err := time.Parse(time.RFC3339, item.Time)
+		if err != nil {
+			return forecast, errors.New(fmt.Sprintf(MetnoTimeParsingErrorMessage, err))
+		}
+
+		temperature := item.Data.Instant.Details.AirTemperature
+		if p.ctx.Config.Spec.Weather.Temperature.Type != "real" {
+			temperature = getApparentTemperature(item.Data.Instant.Details)
+		}
+
+		forecast = append(forecast, ForecastItem{
+			Time:        forecastTime,
+			Temperature: toTemperatureUnit(p.ctx, temperature),
+		})
+	}
+
+	return forecast, nil
+}
+
+// getApparentTemperature return the temperature felt by people, in celsius, from the details of the forecast.
+// Approximation by the Australian Bureau of Meteorology, without the solar radiation, is used
+func getApparentTemperature(details MetnoDetailsSpec) float64 {
+
+	// Water vapour pressure, in hPa
+	vapourPressure := details.RelativeHumidity / 100 * 6.105 *
+		math.Exp(17.27*details.AirTemperature/(237.7+details.AirTemperature))
+
+	return details.AirTemperature + 0.33*vapourPressure - 0.70*details.WindSpeed - 4.00
+}
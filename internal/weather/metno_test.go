@@ -0,0 +1,93 @@
+package weather
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetnoForecast(t *testing.T) {
+
+	// Requests without identification or with too many decimals are rejected, as the real API does
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != MetnoDefaultUserAgent ||
+			r.URL.Query().Get("lat") != "28.1562" || r.URL.Query().Get("lon") != "-16.6359" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"properties": {"timeseries": [
+			{"time": "2026-01-12T00:00:00Z", "data": {"instant": {"details": {
+				"air_temperature": 10, "relative_humidity": 50, "wind_speed": 2}}}},
+			{"time": "2026-01-12T01:00:00Z", "data": {"instant": {"details": {
+				"air_temperature": 20, "relative_humidity": 0, "wind_speed": 0}}}}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		temperatureType string
+		temperatureUnit string
+		userAgent       string
+		expected        []float64
+		expectError     bool
+	}{
+		"real temperature": {
+			temperatureType: "real",
+			temperatureUnit: "celsius",
+			expected:        []float64{10, 20},
+		},
+		"real temperature in fahrenheit": {
+			temperatureType: "real",
+			temperatureUnit: "fahrenheit",
+			expected:        []float64{50, 68},
+		},
+		"apparent temperature": {
+			temperatureType: "apparent",
+			temperatureUnit: "celsius",
+			expected:        []float64{6.6229, 16},
+		},
+		"unknown user agent": {
+			temperatureType: "real",
+			temperatureUnit: "celsius",
+			userAgent:       "unknown",
+			expectError:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newWeatherContext(test.temperatureType, test.temperatureUnit)
+			ctx.Config.Spec.Weather.Metno.URL = server.URL
+			ctx.Config.Spec.Weather.Metno.UserAgent = test.userAgent
+
+			provider, err := NewMetnoProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			forecast, err := provider.GetForecast()
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the forecast %v", forecast)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(forecast) != len(test.expected) {
+				t.Fatalf("expected %d items, got %d", len(test.expected), len(forecast))
+			}
+
+			for index, item := range forecast {
+				if math.Abs(item.Temperature-test.expected[index]) > 0.001 {
+					t.Errorf("expected %g at %s, got %g", test.expected[index], item.Time, item.Temperature)
+				}
+			}
+		})
+	}
+}
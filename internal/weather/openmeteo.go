@@ -0,0 +1,183 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+)
+
+const (
+	OpenMeteoAPIUrl = "https://api.open-meteo.com/v1/forecast"
+
+	// Days requested around today. They cover any evaluation period, no matter the timezone
+	openMeteoPastDays     = 1
+	openMeteoForecastDays = 3
+
+	// Layout of the hourly times of the forecast. They are expressed in GMT, as no timezone is requested
+	openMeteoTimeLayout = "2006-01-02T15:04"
+
+	//
+	OpenMeteoTimeParsingErrorMessage = "weather forecast contains an invalid time: %s"
+)
+
+// OpenMeteoResponseSpec represents the fields available to be sent to Open Meteo.
+// DISCLAIMER: NOT all the fields are covered. Only those that are considered useful to know if it's hot
+// Ref: https://open-meteo.com/en/docs
+type OpenMeteoResponseSpec struct {
+
+	// Geographical WGS84 coordinate of the location
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+
+	GenerationTimeMs float64 `json:"generationtime_ms"`
+
+	UTCOffsetSeconds float64 `json:"utc_offset_seconds"`
+
+	Timezone             string `json:"timezone"`
+	TimezoneAbbreviation string `json:"timezone_abbreviation"`
+
+	Elevation float64 `json:"elevation"`
+
+	HourlyUnits HourlyUnitsSpec `json:"hourly_units"`
+
+	Hourly HourlySpec `json:"hourly"`
+}
+
+// HourlyUnitsSpec TODO
+type HourlyUnitsSpec struct {
+	Time                string `json:"time"`
+	Temperature2m       string `json:"temperature_2m"`
+	ApparentTemperature string `json:"apparent_temperature"`
+}
+
+// HourlySpec TODO
+type HourlySpec struct {
+	Time                []string  `json:"time"`
+	Temperature2m       []float64 `json:"temperature_2m"`
+	ApparentTemperature []float64 `json:"apparent_temperature"`
+}
+
+// OpenMeteoProvider represents a weather provider that retrieves the forecast from Open-Meteo
+type OpenMeteoProvider struct {
+	url             string
+	httpClient      *http.Client
+	latitude        float64
+	longitude       float64
+	temperatureType string
+	temperatureUnit string
+}
+
+// NewOpenMeteoProvider return an Open-Meteo provider for the coordinates defined on 'weather.coordinates'
+func NewOpenMeteoProvider(ctx *v1alpha1.Context) (provider *OpenMeteoProvider, err error) {
+
+	err = checkCoordinates(ctx)
+	if err != nil {
+		return provider, err
+	}
+
+	provider = &OpenMeteoProvider{
+		httpClient:      httpx.GetClient(ctx),
+		url:             OpenMeteoAPIUrl,
+		latitude:        ctx.Config.Spec.Weather.Coordinates.Latitude,
+		longitude:       ctx.Config.Spec.Weather.Coordinates.Longitude,
+		temperatureType: ctx.Config.Spec.Weather.Temperature.Type,
+		temperatureUnit: ctx.Config.Spec.Weather.Temperature.Unit,
+	}
+
+	if ctx.Config.Spec.Weather.OpenMeteo.URL != "" {
+		provider.url = ctx.Config.Spec.Weather.OpenMeteo.URL
+	}
+
+	return provider, nil
+}
+
+// GetForecast return the hourly temperatures from the beginning of yesterday to the end of the day after tomorrow, in GMT
+func (p *OpenMeteoProvider) GetForecast() (forecast Forecast, err error) {
+
+	response, err := p.getApiData()
+	if err != nil {
+		return forecast, err
+	}
+
+	temperatures := response.Hourly.ApparentTemperature
+	if p.temperatureType == "real" {
+		temperatures = response.Hourly.Temperature2m
+	}
+
+	// Both series must be paired, so the longest one is cut
+	for index, hourlyTime := range response.Hourly.Time {
+		if index >= len(temperatures) {
+			break
+		}
+
+		forecastTime, err := time.ParseInLocation(openMeteoTimeLayout, hourlyTime, time.UTC)
+		if err != nil {
+			return forecast, errors.New(fmt.Sprintf(OpenMeteoTimeParsingErrorMessage, err))
+		}
+
+		forecast = append(forecast, ForecastItem{Time: forecastTime, Temperature: temperatures[index]})
+	}
+
+	return forecast, nil
+}
+
+// getApiData return the raw response of Open-Meteo
+func (p *OpenMeteoProvider) getApiData() (response *OpenMeteoResponseSpec, err error) {
+
+	// Select between apparent or real temperature
+	parameterHourly := "apparent_temperature"
+	if p.temperatureType == "real" {
+		parameterHourly = "temperature_2m"
+	}
+
+	// Select between celsius or fahrenheit
+	parameterTemperatureUnit := "celsius"
+	if p.temperatureUnit == "fahrenheit" {
+		parameterTemperatureUnit = "fahrenheit"
+	}
+
+	// Convert floats values to string
+	parameterLatitude := strconv.FormatFloat(p.latitude, 'g', 5, 64)
+	parameterLongitude := strconv.FormatFloat(p.longitude, 'g', 5, 64)
+
+	// Encode everything as URL
+	params := url.Values{}
+	params.Add("latitude", parameterLatitude)
+	params.Add("longitude", parameterLongitude)
+	params.Add("hourly", parameterHourly)
+	params.Add("temperature_unit", parameterTemperatureUnit)
+	params.Add("past_days", strconv.Itoa(openMeteoPastDays))
+	params.Add("forecast_days", strconv.Itoa(openMeteoForecastDays))
+
+	requestUrl, err := url.Parse(p.url)
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(HttpUrlParsingErrorMessage, err))
+	}
+
+	requestUrl.RawQuery = params.Encode()
+
+	httpRequest, err := http.NewRequest(http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(HttpUrlParsingErrorMessage, err))
+	}
+
+	body, err := httpx.Do(p.httpClient, httpRequest)
+	if err != nil {
+		return response, err
+	}
+
+	// Decode response's JSON into a struct
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return response, errors.New(fmt.Sprintf(ResponseDecodingErrorMessage, err))
+	}
+
+	return response, nil
+}
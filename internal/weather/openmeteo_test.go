@@ -0,0 +1,84 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+// newWeatherContext return a context with the coordinates and the temperature settings needed by the providers
+func newWeatherContext(temperatureType string, temperatureUnit string) *v1alpha1.Context {
+
+	ctx := &v1alpha1.Context{Config: &v1alpha1.ConfigSpec{}}
+	ctx.Config.Spec.Weather.Coordinates.Latitude = 28.15623
+	ctx.Config.Spec.Weather.Coordinates.Longitude = -16.63592
+	ctx.Config.Spec.Weather.Temperature.Type = temperatureType
+	ctx.Config.Spec.Weather.Temperature.Unit = temperatureUnit
+
+	return ctx
+}
+
+func TestOpenMeteoForecast(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("temperature_unit") != "fahrenheit" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Series of different lengths, as only the requested one is usually returned
+		_, _ = w.Write([]byte(`{"hourly": {
+			"time": ["2026-01-12T00:00", "2026-01-12T01:00", "2026-01-12T02:00"],
+			"temperature_2m": [50, 51, 52],
+			"apparent_temperature": [45, 46]
+		}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		temperatureType string
+		expected        []float64
+	}{
+		"real temperature": {
+			temperatureType: "real",
+			expected:        []float64{50, 51, 52},
+		},
+		"apparent temperature": {
+			temperatureType: "apparent",
+			expected:        []float64{45, 46},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newWeatherContext(test.temperatureType, "fahrenheit")
+			ctx.Config.Spec.Weather.OpenMeteo.URL = server.URL
+
+			provider, err := NewOpenMeteoProvider(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			forecast, err := provider.GetForecast()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(forecast) != len(test.expected) {
+				t.Fatalf("expected %d items, got %d", len(test.expected), len(forecast))
+			}
+
+			// Times are expressed in GMT
+			start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+			for index, item := range forecast {
+				if !item.Time.Equal(start.Add(time.Duration(index)*time.Hour)) || item.Temperature != test.expected[index] {
+					t.Errorf("expected %g at %s, got %g at %s", test.expected[index],
+						start.Add(time.Duration(index)*time.Hour), item.Temperature, item.Time)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Names of the providers available to be selected on 'weather.provider'
+	ProviderOpenMeteo = "openmeteo"
+	ProviderMetno     = "metno"
+	ProviderFile      = "file"
+	ProviderHttp      = "http"
+
+	//
+	ProviderNotSupportedErrorMessage = "weather provider '%s' is not supported"
+	ResponseDecodingErrorMessage     = "error decoding weather forecast: %s"
+)
+
+// ForecastItem represents the temperature expected from a given moment until the next item of the forecast
+type ForecastItem struct {
+	Time        time.Time
+	Temperature float64
+}
+
+// Forecast represents the temperatures expected for the next days, sorted by time
+type Forecast []ForecastItem
+
+// Temperatures return the temperatures of the forecast, without their moments
+func (f Forecast) Temperatures() (temperatures []float64) {
+	for _, item := range f {
+		temperatures = append(temperatures, item.Temperature)
+	}
+	return temperatures
+}
+
+// WeatherProvider represents a datasource able to retrieve the forecast of the outdoor temperature.
+// Any new datasource must implement this interface to be used by the scheduling logic
+type WeatherProvider interface {
+
+	// GetForecast return the temperatures expected for the next days, of the type and the unit
+	// defined on 'weather.temperature'
+	GetForecast() (Forecast, error)
+}
+
+// NewWeatherProvider return the weather provider selected on 'weather.provider'.
+// Open-Meteo is selected by default when the field is empty
+func NewWeatherProvider(ctx *v1alpha1.Context) (provider WeatherProvider, err error) {
+
+	switch ctx.Config.Spec.Weather.Provider {
+	case "", ProviderOpenMeteo:
+		provider, err = NewOpenMeteoProvider(ctx)
+	case ProviderMetno:
+		provider, err = NewMetnoProvider(ctx)
+	case ProviderFile, ProviderHttp:
+		provider, err = NewStationProvider(ctx, ctx.Config.Spec.Weather.Provider)
+	default:
+		err = errors.New(fmt.Sprintf(ProviderNotSupportedErrorMessage, ctx.Config.Spec.Weather.Provider))
+	}
+
+	return provider, err
+}
+
+// GetForecast return the forecast retrieved from the provider selected on config, sorted by time.
+// When weather is not enabled, an empty forecast is returned
+func GetForecast(ctx *v1alpha1.Context) (forecast Forecast, err error) {
+
+	// Weather not enabled, just throw empty data
+	if !ctx.Config.Spec.Weather.Enabled {
+		return forecast, nil
+	}
+
+	// Check fields regarding temperature
+	err = CheckTemperature(ctx)
+	if err != nil {
+		return forecast, err
+	}
+
+	provider, err := NewWeatherProvider(ctx)
+	if err != nil {
+		return forecast, err
+	}
+
+	forecast, err = provider.GetForecast()
+	if err != nil {
+		return forecast, err
+	}
+
+	sort.SliceStable(forecast, func(i, j int) bool {
+		return forecast[i].Time.Before(forecast[j].Time)
+	})
+
+	return forecast, nil
+}
+
+// checkCoordinates return an error when the coordinates are not defined on 'weather.coordinates'
+func checkCoordinates(ctx *v1alpha1.Context) error {
+
+	if ctx.Config.Spec.Weather.Coordinates.Latitude == 0 || ctx.Config.Spec.Weather.Coordinates.Longitude == 0 {
+		return errors.New(CoordinatesNotFoundErrorMessage)
+	}
+
+	return nil
+}
+
+// toTemperatureUnit return the given temperature in celsius converted to the unit defined on 'weather.temperature.unit'
+func toTemperatureUnit(ctx *v1alpha1.Context, celsius float64) float64 {
+
+	if ctx.Config.Spec.Weather.Temperature.Unit == "fahrenheit" {
+		return celsius*9/5 + 32
+	}
+
+	return celsius
+}
@@ -0,0 +1,49 @@
+package weather
+
+import "testing"
+
+func TestGetForecast(t *testing.T) {
+
+	tests := map[string]struct {
+		enabled  bool
+		provider string
+		unit     string
+
+		expectError bool
+	}{
+		"weather disabled": {
+			provider: "unknown",
+		},
+		"provider not supported": {
+			enabled:     true,
+			provider:    "unknown",
+			unit:        "celsius",
+			expectError: true,
+		},
+		"temperature not defined": {
+			enabled:     true,
+			provider:    ProviderOpenMeteo,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newWeatherContext("real", test.unit)
+			ctx.Config.Spec.Weather.Enabled = test.enabled
+			ctx.Config.Spec.Weather.Provider = test.provider
+			threshold := 12.0
+			ctx.Config.Spec.Weather.Temperature.Threshold = &threshold
+
+			// Errors building the provider are returned instead of exiting
+			forecast, err := GetForecast(ctx)
+			if (err != nil) != test.expectError {
+				t.Fatalf("expected error %t, got '%v'", test.expectError, err)
+			}
+
+			if len(forecast) != 0 {
+				t.Errorf("expected an empty forecast, got %v", forecast)
+			}
+		})
+	}
+}
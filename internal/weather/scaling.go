@@ -0,0 +1,132 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+const (
+	// Possible values for 'weather.scaling.mode'
+	ScalingModeLinear      = "linear"
+	ScalingModeDegreeHours = "degreeHours"
+
+	//
+	ScalingModeNotSupportedErrorMessage    = "config.weather.scaling.mode field must be one of: linear, degreeHours"
+	ScalingDurationParsingErrorMessage     = "config.weather.scaling duration fields must be durations like 2h30m: %s"
+	ScalingTemperaturesErrorMessage        = "config.weather.scaling.warmTemperature field must be greater than coldTemperature"
+	ScalingMaxDurationNotFoundErrorMessage = "config.weather.scaling.maxDuration field is required to scale the active duration"
+)
+
+// GetScaledActiveDuration return the time the device must be turned on according to the forecast for the evaluation
+// period defined on 'weather.evaluation', as configured on 'weather.scaling'. Result is always between 'minDuration'
+// and 'maxDuration'. Given planning window is used by 'window' period.
+//
+// In 'linear' mode, the duration is interpolated between both limits using the aggregated temperature. For heaters,
+// the maximum is reached at 'coldTemperature' and the minimum at 'warmTemperature'. Coolers work the other way.
+//
+// In 'degreeHours' mode, the duration is proportional to the degree hours under 'baseTemperature'
+// for heaters, or above it for coolers
+func GetScaledActiveDuration(ctx *v1alpha1.Context, windowStart time.Time, windowEnd time.Time) (activeDuration time.Duration, err error) {
+
+	scalingConfig := ctx.Config.Spec.Weather.Scaling
+
+	if scalingConfig.MaxDuration == "" {
+		return activeDuration, errors.New(ScalingMaxDurationNotFoundErrorMessage)
+	}
+
+	minDuration := time.Duration(0)
+	if scalingConfig.MinDuration != "" {
+		minDuration, err = time.ParseDuration(scalingConfig.MinDuration)
+		if err != nil {
+			return activeDuration, errors.New(fmt.Sprintf(ScalingDurationParsingErrorMessage, err))
+		}
+	}
+
+	maxDuration, err := time.ParseDuration(scalingConfig.MaxDuration)
+	if err != nil {
+		return activeDuration, errors.New(fmt.Sprintf(ScalingDurationParsingErrorMessage, err))
+	}
+
+	isCooler := ctx.Config.Spec.Device.Type == "cooler"
+
+	switch scalingConfig.Mode {
+	case "", ScalingModeLinear:
+		if scalingConfig.WarmTemperature <= scalingConfig.ColdTemperature {
+			return activeDuration, errors.New(ScalingTemperaturesErrorMessage)
+		}
+
+		temperature, err := GetEvaluatedTemperature(ctx, windowStart, windowEnd)
+		if err != nil {
+			return activeDuration, err
+		}
+
+		// Ratio of the way from the warm temperature to the cold one
+		ratio := (scalingConfig.WarmTemperature - temperature) /
+			(scalingConfig.WarmTemperature - scalingConfig.ColdTemperature)
+		if isCooler {
+			ratio = 1 - ratio
+		}
+
+		ratio = math.Max(0, math.Min(1, ratio))
+		activeDuration = minDuration + time.Duration(ratio*float64(maxDuration-minDuration))
+
+	case ScalingModeDegreeHours:
+		durationPerDegreeHour, err := time.ParseDuration(scalingConfig.DurationPerDegreeHour)
+		if err != nil {
+			return activeDuration, errors.New(fmt.Sprintf(ScalingDurationParsingErrorMessage, err))
+		}
+
+		evaluationStart, evaluationEnd, err := GetEvaluationPeriod(ctx, windowStart, windowEnd)
+		if err != nil {
+			return activeDuration, err
+		}
+
+		forecast, err := GetEvaluatedForecast(ctx, windowStart, windowEnd)
+		if err != nil {
+			return activeDuration, err
+		}
+
+		degreeHours := forecast.DegreeHours(scalingConfig.BaseTemperature, isCooler, evaluationStart, evaluationEnd)
+
+		activeDuration = time.Duration(degreeHours * float64(durationPerDegreeHour))
+		activeDuration = time.Duration(math.Max(float64(minDuration), math.Min(float64(maxDuration), float64(activeDuration))))
+
+	default:
+		return activeDuration, errors.New(ScalingModeNotSupportedErrorMessage)
+	}
+
+	// Minutes are enough to schedule the device
+	return activeDuration.Round(time.Minute), nil
+}
+
+// DegreeHours return the degree hours of the forecast inside the range [start, end), under the given base
+// temperature, or above it for coolers. Each item is weighted by the time until the next one, capped to the range,
+// as providers publish the forecast with different steps
+func (f Forecast) DegreeHours(baseTemperature float64, isCooler bool, start time.Time, end time.Time) (degreeHours float64) {
+
+	for index, item := range f {
+		itemStart, itemEnd := item.Time, f.itemEnd(index)
+		if itemStart.Before(start) {
+			itemStart = start
+		}
+		if itemEnd.After(end) {
+			itemEnd = end
+		}
+
+		if !itemEnd.After(itemStart) {
+			continue
+		}
+
+		difference := baseTemperature - item.Temperature
+		if isCooler {
+			difference = -difference
+		}
+		degreeHours += math.Max(0, difference) * itemEnd.Sub(itemStart).Hours()
+	}
+
+	return degreeHours
+}
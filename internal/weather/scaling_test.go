@@ -0,0 +1,161 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+func TestGetScaledActiveDurationLinear(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	scaling := v1alpha1.ScalingSpec{
+		Enabled:         true,
+		Mode:            ScalingModeLinear,
+		MinDuration:     "1h",
+		MaxDuration:     "5h",
+		WarmTemperature: 20,
+		ColdTemperature: 0,
+	}
+
+	tests := map[string]struct {
+		temperature float64
+		deviceType  string
+		scaling     *v1alpha1.ScalingSpec
+		expected    time.Duration
+		expectError bool
+	}{
+		"halfway between both temperatures": {
+			temperature: 10,
+			expected:    3 * time.Hour,
+		},
+		"close to the cold temperature": {
+			temperature: 5,
+			expected:    4 * time.Hour,
+		},
+		"clamped to the maximum under the cold temperature": {
+			temperature: -5,
+			expected:    5 * time.Hour,
+		},
+		"clamped to the minimum above the warm temperature": {
+			temperature: 25,
+			expected:    time.Hour,
+		},
+		"coolers reach the maximum above the warm temperature": {
+			temperature: 25,
+			deviceType:  "cooler",
+			expected:    5 * time.Hour,
+		},
+		"coolers reach the minimum under the cold temperature": {
+			temperature: -5,
+			deviceType:  "cooler",
+			expected:    time.Hour,
+		},
+		"minimum defaults to zero": {
+			temperature: 25,
+			scaling: &v1alpha1.ScalingSpec{
+				Mode: ScalingModeLinear, MaxDuration: "5h", WarmTemperature: 20, ColdTemperature: 0,
+			},
+			expected: 0,
+		},
+		"warm temperature not above the cold one": {
+			temperature: 10,
+			scaling: &v1alpha1.ScalingSpec{
+				Mode: ScalingModeLinear, MaxDuration: "5h", WarmTemperature: 0, ColdTemperature: 0,
+			},
+			expectError: true,
+		},
+		"maximum duration not found": {
+			temperature: 10,
+			scaling:     &v1alpha1.ScalingSpec{Mode: ScalingModeLinear, WarmTemperature: 20},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newForecastContext(t, start, test.temperature, test.temperature, test.temperature, test.temperature)
+			ctx.Config.Spec.Device.Type = test.deviceType
+
+			ctx.Config.Spec.Weather.Scaling = scaling
+			if test.scaling != nil {
+				ctx.Config.Spec.Weather.Scaling = *test.scaling
+			}
+
+			activeDuration, err := GetScaledActiveDuration(ctx, start, end)
+
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the duration %s", activeDuration)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if activeDuration != test.expected {
+				t.Errorf("expected the duration %s, got %s", test.expected, activeDuration)
+			}
+		})
+	}
+}
+
+func TestForecastDegreeHours(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	end := start.Add(12 * time.Hour)
+
+	// getForecast return items with the given temperatures, separated by the given step
+	getForecast := func(step time.Duration, temperatures ...float64) (forecast Forecast) {
+		for index, temperature := range temperatures {
+			forecast = append(forecast, ForecastItem{Time: start.Add(time.Duration(index) * step), Temperature: temperature})
+		}
+		return forecast
+	}
+
+	tests := map[string]struct {
+		forecast Forecast
+		isCooler bool
+		expected float64
+	}{
+		"hourly items": {
+			forecast: getForecast(time.Hour, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5),
+			expected: 12 * 10,
+		},
+		"items every six hours": {
+			forecast: getForecast(6*time.Hour, 5, 10),
+			expected: 6*10 + 6*5,
+		},
+		"items outside the range are capped": {
+			forecast: Forecast{
+				{Time: start.Add(-3 * time.Hour), Temperature: 5},
+				{Time: start.Add(6 * time.Hour), Temperature: 10},
+			},
+			expected: 6*10 + 6*5,
+		},
+		"temperatures above the base are ignored": {
+			forecast: getForecast(6*time.Hour, 20, 10),
+			expected: 6 * 5,
+		},
+		"coolers count the degrees above the base": {
+			forecast: getForecast(6*time.Hour, 20, 10),
+			isCooler: true,
+			expected: 6 * 5,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			degreeHours := test.forecast.DegreeHours(15, test.isCooler, start, end)
+			if math.Abs(degreeHours-test.expected) > 1e-9 {
+				t.Errorf("expected %.2f degree hours, got %.2f", test.expected, degreeHours)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// ATTENTION:
+// Station providers are intended for those users that have their own weather station, or any other source
+// of temperatures. The config declares where the export is, and which fields hold the timestamp and the temperature.
+// Temperatures must be expressed in the unit defined on 'weather.temperature.unit', as they are used as they are
+
+package weather
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/httpx"
+	"github.com/achetronic/autoheater/internal/mapping"
+)
+
+const (
+	// Possible values for 'weather.station.format'
+	StationFormatCSV  = "csv"
+	StationFormatJSON = "json"
+
+	//
+	StationPathNotFoundErrorMessage       = "config.weather.station.path field is required to use file provider"
+	StationUrlNotFoundErrorMessage        = "config.weather.station.url field is required to use http provider"
+	StationFormatNotSupportedErrorMessage = "weather station format '%s' is not supported. Possible values: csv, json"
+	StationFieldsNotFoundErrorMessage     = "fields 'timestamp' and 'temperature' are required on the field mapping"
+	StationReadingErrorMessage            = "error reading weather station export: %s"
+	StationItemsNotFoundErrorMessage      = "list of temperatures not found on path '%s'"
+	StationFieldNotFoundErrorMessage      = "field '%s' not found on the item %d"
+	StationColumnNotFoundErrorMessage     = "column '%s' not found on weather station export"
+	StationTimestampParsingErrorMessage   = "impossible to parse the timestamp '%v': %s"
+	StationTemperatureParsingErrorMessage = "impossible to parse the temperature '%v'"
+)
+
+// StationProvider represents a weather provider that reads the temperatures exported by a weather station,
+// from a CSV or JSON file on disk, or from any HTTP endpoint returning them
+type StationProvider struct {
+	path       string
+	url        string
+	httpClient *http.Client
+	headers    map[string]string
+	format     string
+	delimiter  rune
+	mapping    v1alpha1.WeatherMappingSpec
+	location   *time.Location
+}
+
+// NewStationProvider return a station provider configured as defined on 'weather.station'.
+// Given name selects whether the export is read from a file or from an HTTP endpoint.
+// When the format is not defined, it's guessed from the extension of the file, or JSON for HTTP endpoints
+func NewStationProvider(ctx *v1alpha1.Context, name string) (provider *StationProvider, err error) {
+
+	stationConfig := ctx.Config.Spec.Weather.Station
+
+	provider = &StationProvider{
+		httpClient: httpx.GetClient(ctx),
+		headers:    stationConfig.Headers,
+		format:     strings.ToLower(stationConfig.Format),
+		delimiter:  ',',
+		mapping:    stationConfig.Mapping,
+	}
+
+	if name == ProviderFile {
+		if stationConfig.Path == "" {
+			return provider, errors.New(StationPathNotFoundErrorMessage)
+		}
+
+		provider.path = stationConfig.Path
+		if provider.format == "" {
+			provider.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(stationConfig.Path)), ".")
+		}
+	} else {
+		if stationConfig.URL == "" {
+			return provider, errors.New(StationUrlNotFoundErrorMessage)
+		}
+
+		provider.url = stationConfig.URL
+		if provider.format == "" {
+			provider.format = StationFormatJSON
+		}
+	}
+
+	if provider.format != StationFormatCSV && provider.format != StationFormatJSON {
+		return provider, errors.New(fmt.Sprintf(StationFormatNotSupportedErrorMessage, provider.format))
+	}
+
+	if provider.mapping.Fields.Timestamp == "" || provider.mapping.Fields.Temperature == "" {
+		return provider, errors.New(StationFieldsNotFoundErrorMessage)
+	}
+
+	if stationConfig.Delimiter != "" {
+		provider.delimiter = []rune(stationConfig.Delimiter)[0]
+	}
+
+	provider.location, err = mapping.LoadLocation(stationConfig.Mapping.Timezone)
+	return provider, err
+}
+
+// GetForecast return all the temperatures found on the export.
+// The file is read again on each request, so it can be edited or replaced at any moment
+func (p *StationProvider) GetForecast() (forecast Forecast, err error) {
+
+	documentBytes, err := p.readExport()
+	if err != nil {
+		return forecast, err
+	}
+
+	if p.format == StationFormatCSV {
+		return p.getCsvForecast(documentBytes)
+	}
+
+	return p.getJsonForecast(documentBytes)
+}
+
+// readExport return the raw content of the export, from disk or from the HTTP endpoint
+func (p *StationProvider) readExport() (documentBytes []byte, err error) {
+
+	if p.path != "" {
+		documentBytes, err = os.ReadFile(p.path)
+		if err != nil {
+			return documentBytes, errors.New(fmt.Sprintf(StationReadingErrorMessage, err))
+		}
+		return documentBytes, nil
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return documentBytes, errors.New(fmt.Sprintf(HttpUrlParsingErrorMessage, err))
+	}
+
+	for headerName, headerValue := range p.headers {
+		httpRequest.Header.Set(headerName, headerValue)
+	}
+
+	return httpx.Do(p.httpClient, httpRequest)
+}
+
+// getCsvForecast return the temperatures found on a CSV export.
+// The first row of the export must contain the names of the columns, used as fields on the mapping
+func (p *StationProvider) getCsvForecast(documentBytes []byte) (forecast Forecast, err error) {
+
+	csvReader := csv.NewReader(strings.NewReader(string(documentBytes)))
+	csvReader.Comma = p.delimiter
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return forecast, errors.New(fmt.Sprintf(StationReadingErrorMessage, err))
+	}
+
+	if len(records) == 0 {
+		return forecast, nil
+	}
+
+	columns := map[string]int{}
+	for index, column := range records[0] {
+		columns[strings.TrimSpace(column)] = index
+	}
+
+	timestampColumn, timestampFound := columns[p.mapping.Fields.Timestamp]
+	if !timestampFound {
+		return forecast, errors.New(fmt.Sprintf(StationColumnNotFoundErrorMessage, p.mapping.Fields.Timestamp))
+	}
+
+	temperatureColumn, temperatureFound := columns[p.mapping.Fields.Temperature]
+	if !temperatureFound {
+		return forecast, errors.New(fmt.Sprintf(StationColumnNotFoundErrorMessage, p.mapping.Fields.Temperature))
+	}
+
+	for _, record := range records[1:] {
+		item, err := p.parseItem(record[timestampColumn], record[temperatureColumn])
+		if err != nil {
+			return forecast, err
+		}
+
+		// The repeated hour of DST changes comes twice with the same timestamp when there is no offset
+		if len(forecast) > 0 {
+			item.Time = mapping.DisambiguateInstant(item.Time, forecast[len(forecast)-1].Time)
+		}
+		forecast = append(forecast, item)
+	}
+
+	return forecast, nil
+}
+
+// getJsonForecast return the temperatures found on a JSON export,
+// following the path and the fields defined on the mapping
+func (p *StationProvider) getJsonForecast(documentBytes []byte) (forecast Forecast, err error) {
+
+	var document interface{}
+	err = json.Unmarshal(documentBytes, &document)
+	if err != nil {
+		return forecast, errors.New(fmt.Sprintf(ResponseDecodingErrorMessage, err))
+	}
+
+	itemsValue, itemsFound := mapping.LookupPath(document, p.mapping.Items)
+	items, itemsAreList := itemsValue.([]interface{})
+	if !itemsFound || !itemsAreList {
+		return forecast, errors.New(fmt.Sprintf(StationItemsNotFoundErrorMessage, p.mapping.Items))
+	}
+
+	for index, rawItem := range items {
+
+		timestampValue, timestampFound := mapping.LookupPath(rawItem, p.mapping.Fields.Timestamp)
+		if !timestampFound {
+			return forecast, errors.New(fmt.Sprintf(StationFieldNotFoundErrorMessage, p.mapping.Fields.Timestamp, index))
+		}
+
+		temperatureValue, temperatureFound := mapping.LookupPath(rawItem, p.mapping.Fields.Temperature)
+		if !temperatureFound {
+			return forecast, errors.New(fmt.Sprintf(StationFieldNotFoundErrorMessage, p.mapping.Fields.Temperature, index))
+		}
+
+		item, err := p.parseItem(timestampValue, temperatureValue)
+		if err != nil {
+			return forecast, err
+		}
+
+		// The repeated hour of DST changes comes twice with the same timestamp when there is no offset
+		if len(forecast) > 0 {
+			item.Time = mapping.DisambiguateInstant(item.Time, forecast[len(forecast)-1].Time)
+		}
+		forecast = append(forecast, item)
+	}
+
+	return forecast, nil
+}
+
+// parseItem return the moment and the temperature from the raw values of an item
+func (p *StationProvider) parseItem(timestampValue interface{}, temperatureValue interface{}) (item ForecastItem, err error) {
+
+	item.Time, err = mapping.ParseTimestamp(timestampValue, p.mapping.TimestampLayout, p.location)
+	if err != nil {
+		return item, errors.New(fmt.Sprintf(StationTimestampParsingErrorMessage, timestampValue, err))
+	}
+
+	item.Temperature, err = mapping.ParseNumber(temperatureValue)
+	if err != nil {
+		return item, errors.New(fmt.Sprintf(StationTemperatureParsingErrorMessage, temperatureValue))
+	}
+
+	return item, nil
+}
@@ -0,0 +1,117 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+)
+
+func TestStationForecast(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data": {"forecast": [
+			{"time": 1768176000, "values": {"temperature": "10.5"}},
+			{"time": 1768179600, "values": {"temperature": 11}}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	csvPath := filepath.Join(t.TempDir(), "forecast.csv")
+	err := os.WriteFile(csvPath, []byte("time;temperature\n2026-01-12 01:00;10.5\n2026-01-12 02:00;11\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		name    string
+		station v1alpha1.WeatherStationSpec
+
+		// The export may be rejected
+		expectError bool
+	}{
+		"json export from an http endpoint": {
+			name: ProviderHttp,
+			station: v1alpha1.WeatherStationSpec{
+				URL:     server.URL,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Mapping: v1alpha1.WeatherMappingSpec{
+					Items:           "data.forecast",
+					Fields:          v1alpha1.WeatherMappingFieldsSpec{Timestamp: "time", Temperature: "values.temperature"},
+					TimestampLayout: "unix",
+				},
+			},
+		},
+		"csv export from a file": {
+			name: ProviderFile,
+			station: v1alpha1.WeatherStationSpec{
+				Path:      csvPath,
+				Delimiter: ";",
+				Mapping: v1alpha1.WeatherMappingSpec{
+					Fields:          v1alpha1.WeatherMappingFieldsSpec{Timestamp: "time", Temperature: "temperature"},
+					TimestampLayout: "2006-01-02 15:04",
+					Timezone:        "Europe/Madrid",
+				},
+			},
+		},
+		"unauthorized http endpoint": {
+			name: ProviderHttp,
+			station: v1alpha1.WeatherStationSpec{
+				URL: server.URL,
+				Mapping: v1alpha1.WeatherMappingSpec{
+					Items:  "data.forecast",
+					Fields: v1alpha1.WeatherMappingFieldsSpec{Timestamp: "time", Temperature: "values.temperature"},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	// Both exports contain the same temperatures, from midnight in GMT
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	expected := []float64{10.5, 11}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := &v1alpha1.Context{Config: &v1alpha1.ConfigSpec{}}
+			ctx.Config.Spec.Weather.Station = test.station
+
+			provider, err := NewStationProvider(ctx, test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			forecast, err := provider.GetForecast()
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got the forecast %v", forecast)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(forecast) != len(expected) {
+				t.Fatalf("expected %d items, got %d", len(expected), len(forecast))
+			}
+
+			for index, item := range forecast {
+				if !item.Time.Equal(start.Add(time.Duration(index)*time.Hour)) || item.Temperature != expected[index] {
+					t.Errorf("expected %g at %s, got %g at %s", expected[index],
+						start.Add(time.Duration(index)*time.Hour), item.Temperature, item.Time)
+				}
+			}
+		})
+	}
+}
@@ -1,155 +1,127 @@
 package weather
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
-	"github.com/achetronic/autoheater/api/v1alpha1"
-	"io"
-	"log"
-	"net/http"
-	"net/url"
-	"strconv"
+	"time"
 
-	"gonum.org/v1/gonum/stat"
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/state"
 )
 
 const (
-	OpenMeteoAPIUrl = "https://api.open-meteo.com/v1/forecast"
+	// Name of the document storing the last decision in the state directory
+	DecisionStateName = "weather-decision"
+
+	//
+	DecisionKeptMessage        = "temperature %.1f is inside the hysteresis band, keeping the decision taken on %s"
+	DecisionReadFailedMessage  = "impossible to read the previous weather decision: %s"
+	DecisionWriteFailedMessage = "impossible to store the weather decision: %s"
 
 	//
 	CoordinatesNotFoundErrorMessage    = "coordinates section is required to evaluate weather"
 	TemperatureNotFoundErrorMessage    = "temperature section is required to evaluate weather"
+	ForecastNotFoundErrorMessage       = "weather forecast has no temperatures to evaluate"
 	HttpUrlParsingErrorMessage         = "error configuring http request: %s"
 	HttpRequestFailedErrorMessage      = "error performing http request: %s"
 	HttpResponseReadFailedErrorMessage = "error reading http response's body: %s"
-)
-
-// OpenMeteoResponseSpec represents the fields available to be sent to Open Meteo.
-// DISCLAIMER: NOT all the fields are covered. Only those that are considered useful to know if it's hot
-// Ref: https://open-meteo.com/en/docs
-type OpenMeteoResponseSpec struct {
-
-	// Geographical WGS84 coordinate of the location
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-
-	GenerationTimeMs float64 `json:"generationtime_ms"`
 
-	UTCOffsetSeconds float64 `json:"utc_offset_seconds"`
-
-	Timezone             string `json:"timezone"`
-	TimezoneAbbreviation string `json:"timezone_abbreviation"`
-
-	Elevation float64 `json:"elevation"`
-
-	HourlyUnits HourlyUnitsSpec `json:"hourly_units"`
-
-	Hourly HourlySpec `json:"hourly"`
-}
-
-// HourlyUnitsSpec TODO
-type HourlyUnitsSpec struct {
-	Time                string `json:"time"`
-	Temperature2m       string `json:"temperature_2m"`
-	ApparentTemperature string `json:"apparent_temperature"`
-}
+	//
+	HysteresisThresholdsErrorMessage = "config.weather.temperature lowThreshold and highThreshold fields must be defined together, " +
+		"and lowThreshold can not be greater than highThreshold"
+)
 
-// HourlySpec TODO
-type HourlySpec struct {
-	Time                []string  `json:"time"`
-	Temperature2m       []float64 `json:"temperature_2m"`
-	ApparentTemperature []float64 `json:"apparent_temperature"`
+// Decision represents the last decision taken about the weather, stored to survive restarts.
+// Its time is the moment when it was taken, even when it's kept later by the hysteresis band
+type Decision struct {
+	Cold        bool      `json:"cold"`
+	Temperature float64   `json:"temperature"`
+	Time        time.Time `json:"time"`
 }
 
-// GetApiData TODO
-func GetApiData(ctx *v1alpha1.Context) (response *OpenMeteoResponseSpec, err error) {
+// IsColdDay return the decision about whether the temperature of the evaluation period, defined on 'weather.evaluation',
+// is under the threshold defined on config. Given planning window is used by 'window' period.
+// When a hysteresis band is defined, days are cold under 'lowThreshold' and warm over 'highThreshold'.
+// Between both, the previous decision is kept, so the device does not flip from one day to another.
+// The decision is not stored, so it can be retried. It's stored by SaveDecision once taken
+func IsColdDay(ctx *v1alpha1.Context, windowStart time.Time, windowEnd time.Time) (decision Decision, err error) {
 
-	// Weather not enabled, just throw empty data
-	if !ctx.Config.Spec.Weather.Enabled {
-		return response, nil
-	}
+	temperatureConfig := ctx.Config.Spec.Weather.Temperature
 
-	// Check fields regarding coordinates
-	if ctx.Config.Spec.Weather.Coordinates.Latitude == 0 || ctx.Config.Spec.Weather.Coordinates.Longitude == 0 {
-		ctx.Logger.Fatal(CoordinatesNotFoundErrorMessage)
+	temperature, err := GetEvaluatedTemperature(ctx, windowStart, windowEnd)
+	if err != nil {
+		return decision, err
 	}
 
-	// Check fields regarding temperature
-	if ctx.Config.Spec.Weather.Temperature.Type == "" ||
-		ctx.Config.Spec.Weather.Temperature.Unit == "" ||
-		ctx.Config.Spec.Weather.Temperature.Threshold <= 0 {
-		ctx.Logger.Fatal(TemperatureNotFoundErrorMessage)
+	decision = Decision{
+		Temperature: temperature,
+		Time:        ctx.Clock.Now(),
 	}
 
-	// Select between apparent or real temperature
-	parameterHourly := "apparent_temperature"
-	if ctx.Config.Spec.Weather.Temperature.Type == "real" {
-		parameterHourly = "temperature_2m"
+	// Thresholds were validated on start by CheckTemperature.
+	// The threshold is optional with a hysteresis band, so the middle of the band is used instead
+	if temperatureConfig.Threshold != nil {
+		decision.Cold = temperature < *temperatureConfig.Threshold
+	} else if temperatureConfig.LowThreshold != nil && temperatureConfig.HighThreshold != nil {
+		decision.Cold = temperature < (*temperatureConfig.LowThreshold+*temperatureConfig.HighThreshold)/2
 	}
 
-	// Select between celsius or fahrenheit
-	parameterTemperatureUnit := "celsius"
-	if ctx.Config.Spec.Weather.Temperature.Unit == "fahrenheit" {
-		parameterTemperatureUnit = "fahrenheit"
+	if temperatureConfig.LowThreshold == nil || temperatureConfig.HighThreshold == nil {
+		return decision, nil
 	}
 
-	// Convert floats values to string
-	parameterLatitude := strconv.FormatFloat(ctx.Config.Spec.Weather.Coordinates.Latitude, 'g', 5, 64)
-	parameterLongitude := strconv.FormatFloat(ctx.Config.Spec.Weather.Coordinates.Longitude, 'g', 5, 64)
-
-	// Encode everything as URL
-	params := url.Values{}
-	params.Add("latitude", parameterLatitude)
-	params.Add("longitude", parameterLongitude)
-	params.Add("hourly", parameterHourly)
-	params.Add("temperature_unit", parameterTemperatureUnit)
-
-	requestUrl, err := url.Parse(OpenMeteoAPIUrl)
-	if err != nil {
-		return response, errors.New(fmt.Sprintf(HttpUrlParsingErrorMessage, err))
+	switch {
+	case temperature < *temperatureConfig.LowThreshold:
+		decision.Cold = true
+	case temperature > *temperatureConfig.HighThreshold:
+		decision.Cold = false
+	default:
+		previousDecision := Decision{}
+		found, err := state.Load(ctx, DecisionStateName, &previousDecision)
+		if err != nil {
+			ctx.Logger.Infof(DecisionReadFailedMessage, err)
+		}
+
+		// Without a previous decision, the threshold or the middle of the band is used
+		if found {
+			decision.Cold = previousDecision.Cold
+			decision.Time = previousDecision.Time
+			ctx.Logger.Infof(DecisionKeptMessage, temperature, previousDecision.Time.Format(time.RFC822))
+		}
 	}
 
-	requestUrl.RawQuery = params.Encode()
-
-	// Send the request and wait for the result
-	resp, err := http.Get(requestUrl.String())
-	if err != nil {
-		return response, errors.New(fmt.Sprintf(HttpRequestFailedErrorMessage, err))
-	}
-	defer resp.Body.Close()
+	return decision, nil
+}
 
-	// Read the request's body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return response, errors.New(fmt.Sprintf(HttpResponseReadFailedErrorMessage, err))
-	}
+// SaveDecision store the given decision, so it's kept by the hysteresis band on next evaluations and restarts
+func SaveDecision(ctx *v1alpha1.Context, decision Decision) {
 
-	// Decode response's JSON into a struct
-	err = json.Unmarshal(body, &response)
+	err := state.Save(ctx, DecisionStateName, decision)
 	if err != nil {
-		log.Print(err)
+		ctx.Logger.Infof(DecisionWriteFailedMessage, err)
 	}
-
-	return response, nil
 }
 
-// IsColdDay return true when temperature's mean for the whole day is under the threshold defined on config
-func IsColdDay(ctx *v1alpha1.Context) (bool, error) {
+// CheckTemperature return an error when the fields on 'weather.temperature' are not valid.
+// The threshold is only required when the day is evaluated as cold or warm without a hysteresis band.
+// It's checked on start, so the scheduler does not fail later
+func CheckTemperature(ctx *v1alpha1.Context) error {
 
-	response, err := GetApiData(ctx)
-	if err != nil {
-		return false, err
+	temperatureConfig := ctx.Config.Spec.Weather.Temperature
+
+	if temperatureConfig.Type == "" || temperatureConfig.Unit == "" {
+		return errors.New(TemperatureNotFoundErrorMessage)
 	}
 
-	meanResult := stat.Mean(response.Hourly.ApparentTemperature, nil)
-	if ctx.Config.Spec.Weather.Temperature.Type == "real" {
-		meanResult = stat.Mean(response.Hourly.Temperature2m, nil)
+	hysteresisEnabled := temperatureConfig.LowThreshold != nil || temperatureConfig.HighThreshold != nil
+	if hysteresisEnabled && (temperatureConfig.LowThreshold == nil || temperatureConfig.HighThreshold == nil ||
+		*temperatureConfig.LowThreshold > *temperatureConfig.HighThreshold) {
+		return errors.New(HysteresisThresholdsErrorMessage)
 	}
 
-	if meanResult >= float64(ctx.Config.Spec.Weather.Temperature.Threshold) {
-		return false, nil
+	// Threshold is not used when scaling the active duration
+	if temperatureConfig.Threshold == nil && !hysteresisEnabled && !ctx.Config.Spec.Weather.Scaling.Enabled {
+		return errors.New(TemperatureNotFoundErrorMessage)
 	}
 
-	return true, nil
+	return nil
 }
@@ -0,0 +1,230 @@
+package weather
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/achetronic/autoheater/api/v1alpha1"
+	"github.com/achetronic/autoheater/internal/clock/clocktest"
+	"github.com/achetronic/autoheater/internal/state"
+	"go.uber.org/zap"
+)
+
+// newForecastContext return a context whose forecast is read from a CSV file with the given hourly temperatures,
+// starting at the given moment, evaluated against a threshold of 12 degrees. The state is stored in a temporary directory
+func newForecastContext(t *testing.T, start time.Time, temperatures ...float64) *v1alpha1.Context {
+
+	lines := []string{"time,temperature"}
+	for index, temperature := range temperatures {
+		lines = append(lines, fmt.Sprintf("%d,%g", start.Add(time.Duration(index)*time.Hour).Unix(), temperature))
+	}
+
+	forecastPath := filepath.Join(t.TempDir(), "forecast.csv")
+	err := os.WriteFile(forecastPath, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newWeatherContext("real", "celsius")
+	ctx.Logger = zap.NewNop().Sugar()
+	ctx.StateDirectory = t.TempDir()
+	ctx.Clock = clocktest.NewFakeClock(start)
+
+	threshold := 12.0
+	ctx.Config.Spec.Weather.Temperature.Threshold = &threshold
+
+	ctx.Config.Spec.Weather.Enabled = true
+	ctx.Config.Spec.Weather.Provider = ProviderFile
+	ctx.Config.Spec.Weather.Evaluation.Period = EvaluationPeriodWindow
+	ctx.Config.Spec.Weather.Station.Path = forecastPath
+	ctx.Config.Spec.Weather.Station.Mapping = v1alpha1.WeatherMappingSpec{
+		Fields:          v1alpha1.WeatherMappingFieldsSpec{Timestamp: "time", Temperature: "temperature"},
+		TimestampLayout: "unix",
+	}
+
+	return ctx
+}
+
+func TestCheckTemperature(t *testing.T) {
+
+	threshold, freezing, low, high := 12.0, -5.0, 10.0, 14.0
+
+	tests := map[string]struct {
+		temperature v1alpha1.TemperatureSpec
+		scaling     bool
+		expectError bool
+	}{
+		"threshold": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius", Threshold: &threshold},
+		},
+		"hysteresis band without threshold": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius", LowThreshold: &low, HighThreshold: &high},
+		},
+		"scaling without threshold": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius"},
+			scaling:     true,
+		},
+		"no threshold": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius"},
+			expectError: true,
+		},
+		"incomplete hysteresis band": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius", Threshold: &threshold, LowThreshold: &low},
+			expectError: true,
+		},
+		"inverted hysteresis band": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius", LowThreshold: &high, HighThreshold: &low},
+			expectError: true,
+		},
+		"threshold below zero": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Unit: "celsius", Threshold: &freezing},
+		},
+		"no unit": {
+			temperature: v1alpha1.TemperatureSpec{Type: "real", Threshold: &threshold},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := &v1alpha1.Context{Config: &v1alpha1.ConfigSpec{}}
+			ctx.Config.Spec.Weather.Temperature = test.temperature
+			ctx.Config.Spec.Weather.Scaling.Enabled = test.scaling
+
+			err := CheckTemperature(ctx)
+			if test.expectError && err == nil {
+				t.Errorf("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("expected no error, got '%s'", err)
+			}
+		})
+	}
+}
+
+func TestIsColdDay(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	threshold, low, high := 12.0, 10.0, 14.0
+
+	tests := map[string]struct {
+		temperature v1alpha1.TemperatureSpec
+
+		// Mean temperature of the window, and the decision stored by a previous evaluation
+		mean     float64
+		previous *Decision
+
+		expectedCold bool
+		expectedTime time.Time
+	}{
+		"under the threshold": {
+			temperature:  v1alpha1.TemperatureSpec{Threshold: &threshold},
+			mean:         11.5,
+			expectedCold: true,
+		},
+		"over the threshold": {
+			temperature: v1alpha1.TemperatureSpec{Threshold: &threshold},
+			mean:        12.5,
+		},
+		"under the band": {
+			temperature:  v1alpha1.TemperatureSpec{LowThreshold: &low, HighThreshold: &high},
+			mean:         9.5,
+			previous:     &Decision{Cold: false, Time: start.AddDate(0, 0, -1)},
+			expectedCold: true,
+		},
+		"over the band": {
+			temperature: v1alpha1.TemperatureSpec{LowThreshold: &low, HighThreshold: &high},
+			mean:        14.5,
+			previous:    &Decision{Cold: true, Time: start.AddDate(0, 0, -1)},
+		},
+		"inside the band keeps a previous cold decision": {
+			temperature:  v1alpha1.TemperatureSpec{Threshold: &threshold, LowThreshold: &low, HighThreshold: &high},
+			mean:         13,
+			previous:     &Decision{Cold: true, Time: start.AddDate(0, 0, -1)},
+			expectedCold: true,
+			expectedTime: start.AddDate(0, 0, -1),
+		},
+		"inside the band keeps a previous warm decision": {
+			temperature:  v1alpha1.TemperatureSpec{Threshold: &threshold, LowThreshold: &low, HighThreshold: &high},
+			mean:         11,
+			previous:     &Decision{Cold: false, Time: start.AddDate(0, 0, -1)},
+			expectedTime: start.AddDate(0, 0, -1),
+		},
+		"inside the band without a previous decision uses the threshold": {
+			temperature:  v1alpha1.TemperatureSpec{Threshold: &threshold, LowThreshold: &low, HighThreshold: &high},
+			mean:         11,
+			expectedCold: true,
+		},
+		"inside the band without a previous decision nor threshold uses the middle of the band": {
+			temperature: v1alpha1.TemperatureSpec{LowThreshold: &low, HighThreshold: &high},
+			mean:        12.5,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			// Temperatures around the mean, as the mean aggregation is used by default
+			ctx := newForecastContext(t, start, test.mean-1, test.mean+1, test.mean-0.5, test.mean+0.5)
+			ctx.Config.Spec.Weather.Temperature.Threshold = test.temperature.Threshold
+			ctx.Config.Spec.Weather.Temperature.LowThreshold = test.temperature.LowThreshold
+			ctx.Config.Spec.Weather.Temperature.HighThreshold = test.temperature.HighThreshold
+
+			if test.previous != nil {
+				SaveDecision(ctx, *test.previous)
+			}
+
+			decision, err := IsColdDay(ctx, start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedTime := test.expectedTime
+			if expectedTime.IsZero() {
+				expectedTime = start
+			}
+
+			if decision.Cold != test.expectedCold || decision.Temperature != test.mean || !decision.Time.Equal(expectedTime) {
+				t.Errorf("expected cold %t at %g taken on %s, got cold %t at %g taken on %s", test.expectedCold,
+					test.mean, expectedTime, decision.Cold, decision.Temperature, decision.Time)
+			}
+		})
+	}
+}
+
+func TestIsColdDayDoesNotStoreTheDecision(t *testing.T) {
+
+	start := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC)
+	low, high := 10.0, 14.0
+
+	ctx := newForecastContext(t, start, 9, 9)
+	ctx.Config.Spec.Weather.Temperature.LowThreshold = &low
+	ctx.Config.Spec.Weather.Temperature.HighThreshold = &high
+
+	// Evaluations retried do not store anything until the decision is saved
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, err := IsColdDay(ctx, start, start.Add(2*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previousDecision := Decision{}
+	found, err := state.Load(ctx, DecisionStateName, &previousDecision)
+	if err != nil || found {
+		t.Fatalf("expected no decision stored, got %v (error '%v')", previousDecision, err)
+	}
+
+	decision, _ := IsColdDay(ctx, start, start.Add(2*time.Hour))
+	SaveDecision(ctx, decision)
+
+	found, err = state.Load(ctx, DecisionStateName, &previousDecision)
+	if err != nil || !found || !previousDecision.Cold {
+		t.Errorf("expected a cold decision stored, got %v (error '%v')", previousDecision, err)
+	}
+}